@@ -5,6 +5,8 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/ikura-hamu/q-cli/internal/config"
 	"github.com/spf13/cobra"
@@ -19,7 +21,9 @@ func NewConfigBare() *ConfigBare {
 	configCmd := &cobra.Command{
 		Use:   "config",
 		Short: "config prints the configuration file path and the current configuration of the CLI",
-		Long:  `configコマンドは、設定ファイルのパスと現在のCLIの設定を表示します。webhook_secretなど、一部の設定はマスクされます。`,
+		Long: `configコマンドは、設定ファイルのパスと現在のCLIの設定を表示します。webhook_secretなど、一部の設定はマスクされます。
+プロファイルがある場合は、すべてのプロファイルと、選択されているプロファイルを表示します。
+それぞれの値には、その値を読み込んだ設定ファイルをコメントで表示します。`,
 	}
 	return &ConfigBare{
 		Command: configCmd,
@@ -44,15 +48,32 @@ func NewConfig(root *Root, confBareCmd *ConfigBare, fr config.FileReader) *Confi
 			return fmt.Errorf("read config: %w", err)
 		}
 
-		allConfig["webhook_secret"] = "********"
+		profile, err := fr.GetProfile()
+		if err != nil {
+			return fmt.Errorf("get profile: %w", err)
+		}
 
-		yamlConfig, err := yaml.Marshal(allConfig)
-		cobra.CheckErr(err)
+		sources, err := fr.GetSources()
+		if err != nil {
+			return fmt.Errorf("get config sources: %w", err)
+		}
 
-		fmt.Printf(`Config file used: %s
+		maskSecrets(allConfig)
 
-%s
-`, fileName, string(yamlConfig))
+		var node yaml.Node
+		cobra.CheckErr(node.Encode(allConfig))
+		annotateSources(&node, "", sources)
+		yamlConfig, err := yaml.Marshal(&node)
+		cobra.CheckErr(err)
+
+		fmt.Printf("Config file used: %s\n", fileName)
+		for _, f := range projectFiles(fileName, sources) {
+			fmt.Printf("Project config file used: %s\n", f)
+		}
+		if profile != "" {
+			fmt.Printf("Profile: %s\n", profile)
+		}
+		fmt.Printf("\n%s\n", string(yamlConfig))
 
 		return nil
 	}
@@ -60,3 +81,56 @@ func NewConfig(root *Root, confBareCmd *ConfigBare, fr config.FileReader) *Confi
 		Command: confBareCmd.Command,
 	}
 }
+
+const maskedSecret = "********"
+
+// maskSecrets masks webhook_secret at the top level and in every profile.
+func maskSecrets(conf config.ConfigValues) {
+	conf["webhook_secret"] = maskedSecret
+	profiles, _ := conf["profiles"].(map[string]any)
+	for _, p := range profiles {
+		if p, ok := p.(map[string]any); ok {
+			if _, ok := p["webhook_secret"]; ok {
+				p["webhook_secret"] = maskedSecret
+			}
+		}
+	}
+}
+
+// annotateSources adds the file each value was read from as a comment.
+// sources is keyed by the dotted path of the values, in lower case like the keys of the config.
+func annotateSources(node *yaml.Node, prefix string, sources map[string]string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := strings.ToLower(keyNode.Value)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if valueNode.Kind == yaml.MappingNode {
+			annotateSources(valueNode, key, sources)
+			continue
+		}
+		source, ok := sources[key]
+		if !ok {
+			continue
+		}
+		// A comment after a block sequence is not on its line, so it is put after the key.
+		if valueNode.Kind == yaml.ScalarNode {
+			valueNode.LineComment = source
+		} else {
+			keyNode.LineComment = source
+		}
+	}
+}
+
+// projectFiles returns the files in sources other than the home config file.
+func projectFiles(homeFile string, sources map[string]string) []string {
+	var files []string
+	for _, f := range sources {
+		if f != homeFile && !slices.Contains(files, f) {
+			files = append(files, f)
+		}
+	}
+	slices.Sort(files)
+	return files
+}
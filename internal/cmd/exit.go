@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/ikura-hamu/q-cli/internal/client"
+	"github.com/ikura-hamu/q-cli/internal/message"
+	"github.com/ikura-hamu/q-cli/internal/secret"
+)
+
+// Exit codes of q. They are stable, so that scripts and CI can rely on them.
+// See docs/exit-codes.md.
+const (
+	ExitOK = 0
+	// ExitFailure is an error not covered by the other codes.
+	ExitFailure = 1
+	// ExitUsage is an invalid flag or argument.
+	ExitUsage = 2
+	// ExitConfig is a missing or invalid configuration.
+	ExitConfig = 3
+	// ExitInput is an input which cannot be sent, e.g. too large, binary or timed out.
+	ExitInput = 4
+	// ExitSend is a failure to send the message to traQ.
+	ExitSend = 5
+	// ExitCanceled is a send canceled by the user.
+	ExitCanceled = 6
+	// ExitSecretDetected is a message blocked by the secret detection, or secrets found by q scan.
+	ExitSecretDetected = 10
+)
+
+var (
+	ErrSendCanceled  = errors.New("send canceled")
+	ErrSecretBlocked = errors.New("the message was not sent because secrets were detected")
+)
+
+// ExitError is an error with the exit code of the process.
+type ExitError struct {
+	Code int
+	Err  error
+	// Reported means the details have already been printed, so the error itself should not be.
+	Reported bool
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+func withExitCode(code int, err error) error {
+	return &ExitError{Code: code, Err: err}
+}
+
+// reported marks the error as already printed, e.g. as findings on stderr.
+func reported(code int, err error) error {
+	return &ExitError{Code: code, Err: err, Reported: true}
+}
+
+// Reported reports whether the error has already been printed by the command.
+func Reported(err error) bool {
+	var exitErr *ExitError
+	return errors.As(err, &exitErr) && exitErr.Reported
+}
+
+// ExitCode returns the exit code for the error returned by a command.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	if _, ok := secret.SecretDetected(err); ok {
+		return ExitSecretDetected
+	}
+
+	switch {
+	case errors.Is(err, ErrSecretBlocked), errors.Is(err, ErrSecretsFound):
+		return ExitSecretDetected
+	case errors.Is(err, ErrSendCanceled), errors.Is(err, message.ErrCanceled):
+		return ExitCanceled
+	case errors.Is(err, message.ErrInputTooLarge), errors.Is(err, message.ErrBinaryInput), errors.Is(err, message.ErrStdinTimeout):
+		return ExitInput
+	case errors.Is(err, ErrChannelNotFound), errors.Is(err, client.ErrChannelNotFound):
+		return ExitConfig
+	}
+	return ExitFailure
+}
@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ikura-hamu/q-cli/internal/pkg/tty"
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/ras0q/goalie"
+)
+
+// secretGuard checks a message for secrets before it is sent.
+type secretGuard struct {
+	sec    secret.SecretDetector
+	redact bool
+	output string
+	// allow is the rules whose findings are sent anyway.
+	allow []secret.CheckerKey
+	// audit records what happened to the messages with findings.
+	audit secret.AuditLog
+	// pending is the event of the last checked message, recorded by sent once it is actually sent.
+	pending *secret.AuditEvent
+	// openTerminal opens the terminal to ask the user on. It returns an error wrapping tty.ErrNoTTY without one.
+	openTerminal func() (terminal, error)
+}
+
+// terminal is where the user is asked what to do with the findings, usually the controlling terminal.
+type terminal interface {
+	io.Writer
+	ReadLine(prompt string) (string, error)
+	Close() error
+}
+
+func openTTY() (terminal, error) {
+	t, err := tty.Open()
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// newSecretGuard validates the allowed rule keys against the rules of sec.
+func newSecretGuard(sec secret.SecretDetector, audit secret.AuditLog, redact bool, output string, allow []string) (*secretGuard, error) {
+	known := make(map[secret.CheckerKey]struct{})
+	for _, c := range sec.Checkers() {
+		known[c.Key] = struct{}{}
+	}
+
+	keys := make([]secret.CheckerKey, 0, len(allow))
+	for _, a := range allow {
+		key := secret.CheckerKey(strings.TrimSpace(a))
+		if key == "" {
+			continue
+		}
+		if _, ok := known[key]; !ok {
+			return nil, fmt.Errorf("--allow-secrets: unknown secret rule '%s'. See 'q secret rules' for the available rules", key)
+		}
+		keys = append(keys, key)
+	}
+
+	return &secretGuard{sec: sec, redact: redact, output: output, allow: keys, audit: audit, openTerminal: openTTY}, nil
+}
+
+// check returns the message to send. It returns false when the message must not be sent.
+// The findings are printed to stderr, so that they do not mix with the output of a pipeline.
+// In redact mode, secrets are masked and a summary is printed instead of refusing to send.
+// Otherwise, findings with the "warn" severity are only printed,
+// and for the others the user is asked on the controlling terminal whether to send anyway, redact or cancel.
+// The severities of the channel are used. A blocked message is recorded in the audit log at once,
+// and a message to send is recorded by sent, so that the log does not claim what did not happen.
+func (sg *secretGuard) check(ctx context.Context, message string, channel string) (string, bool, error) {
+	sec := sg.sec.ForChannel(channel)
+	if sg.redact {
+		return sg.redactMessage(ctx, sec, message, channel)
+	}
+
+	err := sec.Detect(ctx, message)
+	findings, ok := secret.SecretDetected(err)
+	if !ok && err != nil {
+		return "", false, fmt.Errorf("failed to detect secret: %w", err)
+	}
+
+	var allowed []secret.Finding
+	findings = slices.DeleteFunc(findings, func(f secret.Finding) bool {
+		if slices.Contains(sg.allow, f.Key) {
+			allowed = append(allowed, f)
+			return true
+		}
+		return false
+	})
+	if len(allowed) > 0 {
+		keys := make([]secret.CheckerKey, 0, len(allowed))
+		for _, f := range allowed {
+			keys = append(keys, f.Key)
+		}
+		slices.Sort(keys)
+		fmt.Fprintf(os.Stderr, "Secrets allowed by --allow-secrets: %s\n", strings.Join(secretKeys(slices.Compact(keys)), ", "))
+	}
+	if len(findings) == 0 {
+		sg.prepare(channel, secret.AuditOverridden, allowed)
+		return message, true, nil
+	}
+
+	if err := printFindings(os.Stderr, findings, sg.output); err != nil {
+		return "", false, err
+	}
+
+	var confirming, blocking []secret.Finding
+	for _, f := range findings {
+		switch f.Severity {
+		case secret.SeverityWarn:
+		case secret.SeverityConfirm:
+			confirming = append(confirming, f)
+		default:
+			blocking = append(blocking, f)
+		}
+	}
+
+	var checked string
+	switch {
+	case len(blocking) > 0:
+		// Sending anyway requires typing the rule keys of the blocking findings.
+		checked, ok, err = sg.resolve(ctx, sec, message, channel, blocking)
+	case len(confirming) > 0:
+		checked, ok, err = sg.resolve(ctx, sec, message, channel, nil)
+	default:
+		action := secret.AuditWarned
+		if len(allowed) > 0 {
+			action = secret.AuditOverridden
+		}
+		sg.prepare(channel, action, slices.Concat(allowed, findings))
+		return message, true, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	switch {
+	case !ok:
+		sg.record(secret.AuditEvent{Channel: channel, Action: secret.AuditBlocked, Findings: auditFindings(findings)})
+		if sg.output == outputText {
+			fmt.Fprintln(os.Stderr, "The message was not sent.")
+		}
+	case checked == message:
+		sg.prepare(channel, secret.AuditOverridden, slices.Concat(allowed, findings))
+	}
+	return checked, ok, nil
+}
+
+func (sg *secretGuard) redactMessage(ctx context.Context, sec secret.SecretDetector, message string, channel string) (string, bool, error) {
+	redacted, redactions, err := sec.Redact(ctx, message)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to redact secret: %w", err)
+	}
+	var findings []secret.AuditFinding
+	if len(redactions) > 0 {
+		summary := make([]string, 0, len(redactions))
+		for _, r := range redactions {
+			summary = append(summary, fmt.Sprintf("%s x%d", r.Key, r.Count))
+			for _, fp := range r.Fingerprints {
+				findings = append(findings, secret.AuditFinding{Rule: r.Key, Fingerprint: fp})
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Redacted secrets: %s\n", strings.Join(summary, ", "))
+	}
+	sg.pending = nil
+	if len(findings) > 0 {
+		sg.pending = &secret.AuditEvent{Channel: channel, Action: secret.AuditRedacted, Findings: findings}
+	}
+	return redacted, true, nil
+}
+
+// prepare keeps the event of the message to send until sent. A message without findings has no event.
+func (sg *secretGuard) prepare(channel string, action secret.AuditAction, findings []secret.Finding) {
+	sg.pending = nil
+	if len(findings) > 0 {
+		sg.pending = &secret.AuditEvent{Channel: channel, Action: action, Findings: auditFindings(findings)}
+	}
+}
+
+// sent records the event of the last checked message, which has been sent.
+func (sg *secretGuard) sent() {
+	if sg.pending != nil {
+		sg.record(*sg.pending)
+		sg.pending = nil
+	}
+}
+
+// record appends an event to the audit log. A failure is only printed, so that it does not stop sending.
+func (sg *secretGuard) record(event secret.AuditEvent) {
+	if sg.audit == nil || len(event.Findings) == 0 {
+		return
+	}
+	event.Time = time.Now()
+	if err := sg.audit.Record(event); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write the audit log: %v\n", err)
+	}
+}
+
+func auditFindings(findings []secret.Finding) []secret.AuditFinding {
+	res := make([]secret.AuditFinding, 0, len(findings))
+	for _, f := range findings {
+		res = append(res, secret.AuditFinding{Rule: f.Key, Fingerprint: f.Fingerprint})
+	}
+	return res
+}
+
+// resolve asks the user what to do with the findings. Sending anyway requires typing the rule key of every finding in typed.
+// Without a terminal, the message is not sent.
+func (sg *secretGuard) resolve(ctx context.Context, sec secret.SecretDetector, message string, channel string, typed []secret.Finding) (_ string, _ bool, err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	t, err := sg.openTerminal()
+	if errors.Is(err, tty.ErrNoTTY) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	defer g.Guard(t.Close)
+
+	l, err := t.ReadLine("[s]end anyway / [r]edact / [c]ancel: ")
+	if errors.Is(err, io.EOF) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(l)) {
+	case "s", "send":
+		keys := make([]secret.CheckerKey, 0, len(typed))
+		for _, f := range typed {
+			keys = append(keys, f.Key)
+		}
+		slices.Sort(keys)
+		for _, key := range slices.Compact(keys) {
+			l, err := t.ReadLine(fmt.Sprintf("Type the rule key '%s' to send it anyway: ", key))
+			if err != nil && !errors.Is(err, io.EOF) {
+				return "", false, err
+			}
+			if strings.TrimSpace(l) != string(key) {
+				_, _ = fmt.Fprintln(t, "The rule key does not match.")
+				return "", false, nil
+			}
+		}
+		return message, true, nil
+	case "r", "redact":
+		return sg.redactMessage(ctx, sec, message, channel)
+	default:
+		return "", false, nil
+	}
+}
+
+func secretKeys(keys []secret.CheckerKey) []string {
+	res := make([]string, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, string(k))
+	}
+	return res
+}
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+func validateOutput(output string) error {
+	if output != outputText && output != outputJSON {
+		return fmt.Errorf("invalid output format '%s': must be '%s' or '%s'", output, outputText, outputJSON)
+	}
+	return nil
+}
+
+// printFindings prints the findings as a table, or as JSON when output is "json".
+func printFindings(w io.Writer, findings []secret.Finding, output string) error {
+	if output == outputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Findings []secret.Finding `json:"findings"`
+		}{findings}); err != nil {
+			return fmt.Errorf("encode findings: %w", err)
+		}
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(w, "%d secret(s) detected.\n", len(findings))
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "SEVERITY\tRULE\tLINE\tCOLUMN\tDESCRIPTION\tMATCH\tFINGERPRINT")
+	for _, f := range findings {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\t%s\n", f.Severity, f.Rule(), f.Line, f.Column, f.Description, f.Excerpt, f.Fingerprint)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("print findings: %w", err)
+	}
+	_, _ = fmt.Fprintf(w, "If a finding is not a secret, run 'q secret allow <fingerprint>' or add '%s' to the line.\n", secret.AllowMarker)
+	return nil
+}
@@ -0,0 +1,357 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ikura-hamu/q-cli/internal/pkg/tty"
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDetector reports the findings for every message, and redacts them by their ranges.
+type fakeDetector struct {
+	keys     []secret.CheckerKey
+	findings []secret.Finding
+}
+
+var _ secret.SecretDetector = (*fakeDetector)(nil)
+
+func (d *fakeDetector) Detect(_ context.Context, _ string) error {
+	if len(d.findings) == 0 {
+		return nil
+	}
+	return secret.NewErrSecretDetected(slices.Clone(d.findings))
+}
+
+func (d *fakeDetector) DetectReader(ctx context.Context, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return d.Detect(ctx, string(b))
+}
+
+func (d *fakeDetector) Redact(_ context.Context, message string) (string, []secret.Redaction, error) {
+	var redactions []secret.Redaction
+	for _, f := range slices.Backward(d.findings) {
+		message = message[:f.Start] + fmt.Sprintf("[REDACTED:%s]", f.Key) + message[f.End:]
+		redactions = append(redactions, secret.Redaction{Key: f.Key, Count: 1, Fingerprints: []string{f.Fingerprint}})
+	}
+	return message, redactions, nil
+}
+
+func (d *fakeDetector) Checkers() []secret.CheckerInfo {
+	infos := make([]secret.CheckerInfo, 0, len(d.keys))
+	for _, key := range d.keys {
+		infos = append(infos, secret.CheckerInfo{Key: key, Active: true})
+	}
+	return infos
+}
+
+func (d *fakeDetector) ForChannel(string) secret.SecretDetector {
+	return d
+}
+
+// fakeTerminal answers the prompts with lines, and then with io.EOF.
+type fakeTerminal struct {
+	bytes.Buffer
+	lines []string
+}
+
+func (t *fakeTerminal) ReadLine(prompt string) (string, error) {
+	t.WriteString(prompt)
+	if len(t.lines) == 0 {
+		return "", io.EOF
+	}
+	l := t.lines[0]
+	t.lines = t.lines[1:]
+	return l, nil
+}
+
+func (t *fakeTerminal) Close() error {
+	return nil
+}
+
+const guardTestMessage = "github: ghp_secret aws: AKIA_secret"
+
+// guardTestFinding returns the finding of the secret of the rule in guardTestMessage.
+func guardTestFinding(key secret.CheckerKey, severity secret.Severity) secret.Finding {
+	value := map[secret.CheckerKey]string{"github": "ghp_secret", "aws": "AKIA_secret"}[key]
+	start := strings.Index(guardTestMessage, value)
+	return secret.Finding{
+		Key: key, Severity: severity, Line: 1, Column: start + 1,
+		Fingerprint: "fp-" + string(key), Start: start, End: start + len(value),
+	}
+}
+
+func TestNewSecretGuard(t *testing.T) {
+	sec := &fakeDetector{keys: []secret.CheckerKey{"aws", "github"}}
+
+	sg, err := newSecretGuard(sec, nil, false, outputText, []string{"github", " aws ", ""})
+	require.NoError(t, err)
+	assert.Equal(t, []secret.CheckerKey{"github", "aws"}, sg.allow)
+
+	_, err = newSecretGuard(sec, nil, false, outputText, []string{"github", "unknown"})
+	assert.Error(t, err, "unknown rule")
+}
+
+func TestSecretGuard_check(t *testing.T) {
+	test := map[string]struct {
+		findings []secret.Finding
+		allow    []string
+		redact   bool
+		// lines are the answers on the terminal. nil means that there is no terminal.
+		lines       []string
+		wantMessage string
+		wantOK      bool
+	}{
+		"検出なし": {
+			wantMessage: guardTestMessage,
+			wantOK:      true,
+		},
+		"--allow-secretsで許可したルールは送信": {
+			findings:    []secret.Finding{guardTestFinding("github", secret.SeverityBlock)},
+			allow:       []string{"github"},
+			wantMessage: guardTestMessage,
+			wantOK:      true,
+		},
+		"--allow-secretsで許可していないルールは送信しない": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityBlock), guardTestFinding("aws", secret.SeverityBlock)},
+			allow:    []string{"github"},
+			wantOK:   false,
+		},
+		"warnだけなら送信": {
+			findings:    []secret.Finding{guardTestFinding("github", secret.SeverityWarn)},
+			wantMessage: guardTestMessage,
+			wantOK:      true,
+		},
+		"confirmで端末がなければ送信しない": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityConfirm)},
+			wantOK:   false,
+		},
+		"confirmでsend": {
+			findings:    []secret.Finding{guardTestFinding("github", secret.SeverityConfirm)},
+			lines:       []string{"s"},
+			wantMessage: guardTestMessage,
+			wantOK:      true,
+		},
+		"blockでルールのキーを入力して送信": {
+			findings:    []secret.Finding{guardTestFinding("github", secret.SeverityBlock)},
+			lines:       []string{"send", "github"},
+			wantMessage: guardTestMessage,
+			wantOK:      true,
+		},
+		"blockでルールのキーを間違えると送信しない": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityBlock)},
+			lines:    []string{"s", "aws"},
+			wantOK:   false,
+		},
+		"blockでルールのキーを入力しないと送信しない": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityBlock)},
+			lines:    []string{"s"},
+			wantOK:   false,
+		},
+		"redactを選ぶ": {
+			findings:    []secret.Finding{guardTestFinding("github", secret.SeverityBlock)},
+			lines:       []string{"r"},
+			wantMessage: "github: [REDACTED:github] aws: AKIA_secret",
+			wantOK:      true,
+		},
+		"cancelを選ぶ": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityConfirm)},
+			lines:    []string{"c"},
+			wantOK:   false,
+		},
+		"redactモード": {
+			findings:    []secret.Finding{guardTestFinding("github", secret.SeverityBlock), guardTestFinding("aws", secret.SeverityWarn)},
+			redact:      true,
+			wantMessage: "github: [REDACTED:github] aws: [REDACTED:aws]",
+			wantOK:      true,
+		},
+	}
+
+	for description, tt := range test {
+		t.Run(description, func(t *testing.T) {
+			sec := &fakeDetector{keys: []secret.CheckerKey{"aws", "github"}, findings: tt.findings}
+			sg, err := newSecretGuard(sec, nil, tt.redact, outputText, tt.allow)
+			require.NoError(t, err)
+			sg.openTerminal = func() (terminal, error) {
+				if tt.lines == nil {
+					return nil, fmt.Errorf("open: %w", tty.ErrNoTTY)
+				}
+				return &fakeTerminal{lines: slices.Clone(tt.lines)}, nil
+			}
+
+			message, ok, err := sg.check(context.Background(), guardTestMessage, "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.wantMessage, message)
+			}
+		})
+	}
+}
+
+// fakeAuditLog keeps the recorded events in memory.
+type fakeAuditLog struct {
+	events []secret.AuditEvent
+}
+
+var _ secret.AuditLog = (*fakeAuditLog)(nil)
+
+func (l *fakeAuditLog) Path() string {
+	return "audit.jsonl"
+}
+
+func (l *fakeAuditLog) Record(event secret.AuditEvent) error {
+	l.events = append(l.events, event)
+	return nil
+}
+
+func (l *fakeAuditLog) Events() ([]secret.AuditEvent, error) {
+	return l.events, nil
+}
+
+func TestSecretGuard_audit(t *testing.T) {
+	github := secret.AuditFinding{Rule: "github", Fingerprint: "fp-github"}
+	aws := secret.AuditFinding{Rule: "aws", Fingerprint: "fp-aws"}
+
+	test := map[string]struct {
+		findings []secret.Finding
+		allow    []string
+		redact   bool
+		lines    []string
+		// sent is whether the message is sent successfully after the check.
+		sent bool
+		want []secret.AuditEvent
+	}{
+		"検出なし": {
+			sent: true,
+		},
+		"端末がなくて送信しない": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityConfirm)},
+			want:     []secret.AuditEvent{{Action: secret.AuditBlocked, Findings: []secret.AuditFinding{github}}},
+		},
+		"cancelを選ぶ": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityConfirm)},
+			lines:    []string{"c"},
+			want:     []secret.AuditEvent{{Action: secret.AuditBlocked, Findings: []secret.AuditFinding{github}}},
+		},
+		"blockは許可したルールを含めない": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityBlock), guardTestFinding("aws", secret.SeverityBlock)},
+			allow:    []string{"github"},
+			want:     []secret.AuditEvent{{Action: secret.AuditBlocked, Findings: []secret.AuditFinding{aws}}},
+		},
+		"warnを送信": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityWarn)},
+			sent:     true,
+			want:     []secret.AuditEvent{{Action: secret.AuditWarned, Findings: []secret.AuditFinding{github}}},
+		},
+		"warnの送信に失敗": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityWarn)},
+		},
+		"--allow-secretsで送信": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityBlock)},
+			allow:    []string{"github"},
+			sent:     true,
+			want:     []secret.AuditEvent{{Action: secret.AuditOverridden, Findings: []secret.AuditFinding{github}}},
+		},
+		"--allow-secretsの送信に失敗": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityBlock)},
+			allow:    []string{"github"},
+		},
+		"--allow-secretsとwarnは同じイベント": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityBlock), guardTestFinding("aws", secret.SeverityWarn)},
+			allow:    []string{"github"},
+			sent:     true,
+			want:     []secret.AuditEvent{{Action: secret.AuditOverridden, Findings: []secret.AuditFinding{github, aws}}},
+		},
+		"sendを選ぶ": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityConfirm)},
+			lines:    []string{"s"},
+			sent:     true,
+			want:     []secret.AuditEvent{{Action: secret.AuditOverridden, Findings: []secret.AuditFinding{github}}},
+		},
+		"redactを選ぶ": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityConfirm)},
+			lines:    []string{"r"},
+			sent:     true,
+			want:     []secret.AuditEvent{{Action: secret.AuditRedacted, Findings: []secret.AuditFinding{github}}},
+		},
+		"redactモードの送信に失敗": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityBlock)},
+			redact:   true,
+		},
+		"redactモード": {
+			findings: []secret.Finding{guardTestFinding("github", secret.SeverityBlock)},
+			redact:   true,
+			sent:     true,
+			want:     []secret.AuditEvent{{Action: secret.AuditRedacted, Findings: []secret.AuditFinding{github}}},
+		},
+	}
+
+	for description, tt := range test {
+		t.Run(description, func(t *testing.T) {
+			sec := &fakeDetector{keys: []secret.CheckerKey{"aws", "github"}, findings: tt.findings}
+			audit := &fakeAuditLog{}
+			sg, err := newSecretGuard(sec, audit, tt.redact, outputText, tt.allow)
+			require.NoError(t, err)
+			sg.openTerminal = func() (terminal, error) {
+				if tt.lines == nil {
+					return nil, fmt.Errorf("open: %w", tty.ErrNoTTY)
+				}
+				return &fakeTerminal{lines: slices.Clone(tt.lines)}, nil
+			}
+
+			_, ok, err := sg.check(context.Background(), guardTestMessage, "")
+			require.NoError(t, err)
+			if ok && tt.sent {
+				sg.sent()
+			}
+
+			for i := range audit.events {
+				assert.False(t, audit.events[i].Time.IsZero())
+				audit.events[i].Time = time.Time{}
+			}
+			assert.Equal(t, tt.want, audit.events)
+		})
+	}
+}
+
+func TestSecretGuard_audit_channel(t *testing.T) {
+	// A channel which blocks the message does not discard the event of the message checked before.
+	audit := &fakeAuditLog{}
+	sec := &fakeDetector{keys: []secret.CheckerKey{"github"}, findings: []secret.Finding{guardTestFinding("github", secret.SeverityWarn)}}
+	sg, err := newSecretGuard(sec, audit, false, outputText, nil)
+	require.NoError(t, err)
+	sg.openTerminal = func() (terminal, error) {
+		return nil, fmt.Errorf("open: %w", tty.ErrNoTTY)
+	}
+
+	_, ok, err := sg.check(context.Background(), guardTestMessage, "")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	sec.findings = []secret.Finding{guardTestFinding("github", secret.SeverityBlock)}
+	_, ok, err = sg.check(context.Background(), guardTestMessage, "strict")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	sg.sent()
+	actions := make([]secret.AuditAction, 0, len(audit.events))
+	channels := make([]string, 0, len(audit.events))
+	for _, e := range audit.events {
+		actions = append(actions, e.Action)
+		channels = append(channels, e.Channel)
+	}
+	assert.Equal(t, []secret.AuditAction{secret.AuditBlocked, secret.AuditWarned}, actions)
+	assert.Equal(t, []string{"strict", ""}, channels)
+}
@@ -4,6 +4,8 @@ Copyright © 2024 ikura-hamu 104292023+ikura-hamu@users.noreply.github.com
 package cmd
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -11,14 +13,17 @@ import (
 	"runtime/debug"
 	"strings"
 
+	"github.com/guregu/null/v6"
 	"github.com/ikura-hamu/q-cli/internal/client"
 	"github.com/ikura-hamu/q-cli/internal/config"
+	"github.com/ikura-hamu/q-cli/internal/editor"
 	"github.com/ikura-hamu/q-cli/internal/message"
+	"github.com/ikura-hamu/q-cli/internal/pkg/tty"
 	"github.com/ikura-hamu/q-cli/internal/pkg/types"
+	"github.com/ikura-hamu/q-cli/internal/render"
 	"github.com/ikura-hamu/q-cli/internal/secret"
 	"github.com/ras0q/goalie"
 	"github.com/spf13/cobra"
-	"golang.org/x/term"
 )
 
 var (
@@ -37,25 +42,46 @@ func NewRootBare() *RootBare {
 	rootCmd := &cobra.Command{
 		TraverseChildren: true,
 		Use:              "q [message]",
+		SilenceErrors:    true,
+		SilenceUsage:     true,
 		Example:          "q print(\"Hello, world!\") -c -l py",
 		Short:            "traQ Webhook CLI",
 		Long: `"q-cli" は、traQにWebhookを使ってメッセージを送信するためのCLIツールです。設定に基づいてWebhookを送信します。
 設定は設定ファイルに記述するか、環境変数で指定することができます。
 メッセージは標準入力からも受け取ることができます。
-設定ファイルの場所は、何も指定しない場合、$HOME/.q-cli.yaml です。`,
+端末で引数なしで実行すると、複数行のメッセージを入力できます。Ctrl-Dで送信、Escでキャンセルします。
+設定ファイルの場所は、何も指定しない場合、$HOME/.q-cli.yaml です。
+シークレットが検出されて送信しなかった場合は終了コード10で終了します。その他の終了コードはドキュメントを参照してください。`,
 	}
+	rootCmd.SetFlagErrorFunc(usageError)
 	return &RootBare{
 		Command: rootCmd,
 	}
 }
 
+// usageError makes an invalid flag or argument exit with ExitUsage.
+func usageError(c *cobra.Command, err error) error {
+	return withExitCode(ExitUsage, fmt.Errorf("%w\nRun '%s --help' for usage.", err, c.CommandPath()))
+}
+
+// usageArgs wraps the validation of the arguments, so that its errors exit with ExitUsage like the flag errors.
+func usageArgs(args cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(c *cobra.Command, a []string) error {
+		if err := args(c, a); err != nil {
+			return usageError(c, err)
+		}
+		return nil
+	}
+}
+
 func NewRoot[Client client.Client](rootCmd *RootBare, fileConf config.File, rootConf config.Root,
-	clFactory types.Factory[Client], mes message.Message, sec secret.SecretDetector) *Root {
+	clFactory types.Factory[Client], mes message.Message, secFactory types.Factory[secret.SecretDetector],
+	auditFactory types.Factory[secret.AuditLog], ed editor.Editor, tmplFactory types.Factory[config.Template]) *Root {
 
 	rootCmd.RunE = func(cmd *cobra.Command, args []string) error {
 		cl, err := clFactory()
 		if err != nil {
-			return fmt.Errorf("create client: %w", err)
+			return withExitCode(ExitConfig, fmt.Errorf("create client: %w", err))
 		}
 
 		if v, err := rootConf.GetVersion(); err != nil {
@@ -77,50 +103,151 @@ func NewRoot[Client client.Client](rootCmd *RootBare, fileConf config.File, root
 			return fmt.Errorf("get code block lang: %w", err)
 		}
 
-		messageStr, err := mes.BuildMessage(args, message.Option{
+		maxBytes, err := rootConf.GetMaxBytes()
+		if err != nil {
+			return fmt.Errorf("get max bytes: %w", err)
+		}
+
+		headLines, err := rootConf.GetHeadLines()
+		if err != nil {
+			return fmt.Errorf("get head lines: %w", err)
+		}
+
+		tailLines, err := rootConf.GetTailLines()
+		if err != nil {
+			return fmt.Errorf("get tail lines: %w", err)
+		}
+
+		withStdin, err := rootConf.GetWithStdin()
+		if err != nil {
+			return fmt.Errorf("get with stdin: %w", err)
+		}
+
+		stdinTimeout, err := rootConf.GetStdinTimeout()
+		if err != nil {
+			return fmt.Errorf("get stdin timeout: %w", err)
+		}
+
+		channelName, err := rootConf.GetChannelName()
+		if err != nil {
+			return fmt.Errorf("get channel name: %w", err)
+		}
+
+		edit, err := rootConf.GetEdit()
+		if err != nil {
+			return fmt.Errorf("get edit: %w", err)
+		}
+
+		option := message.Option{
 			CodeBlock:     codeBlock,
 			CodeBlockLang: codeBlockLang.String,
-		})
+			MaxBytes:      maxBytes,
+			HeadLines:     headLines,
+			TailLines:     tailLines,
+			WithStdin:     withStdin,
+			StdinTimeout:  stdinTimeout,
+			NoPrompt:      edit,
+		}
+		content, err := mes.ReadMessage(args, option)
+		if errors.Is(err, message.ErrCanceled) {
+			return ErrSendCanceled
+		}
 		if err != nil {
 			return fmt.Errorf("failed to build message: %w", err)
 		}
+		messageStr := mes.FormatMessage(content, option)
 
-		err = sec.Detect(ctx, messageStr)
-		if detectMes, ok := secret.SecretDetected(err); ok {
-			fmt.Println(detectMes)
-			return nil
+		redact, err := rootConf.GetRedact()
+		if err != nil {
+			return fmt.Errorf("get redact: %w", err)
 		}
+		output, err := rootConf.GetOutput()
 		if err != nil {
-			return fmt.Errorf("failed to detect secret: %w", err)
+			return fmt.Errorf("get output: %w", err)
+		}
+		if err := validateOutput(output); err != nil {
+			return withExitCode(ExitUsage, err)
 		}
 
-		channelName, err := rootConf.GetChannelName()
+		allowSecrets, err := rootConf.GetAllowSecrets()
 		if err != nil {
-			return fmt.Errorf("get channel name: %w", err)
+			return fmt.Errorf("get allow secrets: %w", err)
 		}
 
-		printBeforeSend, err := rootConf.GetPrintBeforeSend()
+		sec, err := secFactory()
 		if err != nil {
-			return fmt.Errorf("get print before send: %w", err)
+			return withExitCode(ExitConfig, fmt.Errorf("create secret detector: %w", err))
 		}
-		if printBeforeSend {
-			ok, err := checkMessage(messageStr)
+
+		audit, err := auditFactory()
+		if err != nil {
+			return withExitCode(ExitConfig, fmt.Errorf("open audit log: %w", err))
+		}
+
+		guard, err := newSecretGuard(sec, audit, redact, output, allowSecrets)
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+
+		if edit {
+			template, err := rootConf.GetTemplate()
+			if err != nil {
+				return fmt.Errorf("get template: %w", err)
+			}
+			// The content is checked rather than messageStr, because -c makes an empty body a code block.
+			if content.Caption == "" && content.Body == "" && template.Valid {
+				tmplConf, err := tmplFactory()
+				if err != nil {
+					return withExitCode(ExitConfig, fmt.Errorf("create template config: %w", err))
+				}
+				tmpl, err := tmplConf.GetTemplate(template.String)
+				if err != nil {
+					return withExitCode(ExitConfig, fmt.Errorf("get template: %w", err))
+				}
+				// Like a message, the template is put in a code block with -c.
+				messageStr = mes.FormatMessage(message.Content{Body: tmpl}, option)
+			}
+
+			messageStr, err = editMessage(ctx, ed, guard, messageStr, channelName)
+			if err != nil {
+				return err
+			}
+			content, option.CodeBlock = message.Content{Body: messageStr}, false
+		} else {
+			var ok bool
+			content, option, ok, err = checkContent(ctx, guard, mes, content, option, channelName.String)
 			if err != nil {
-				return fmt.Errorf("failed to check message: %w", err)
+				return err
 			}
 			if !ok {
-				fmt.Println("Send canceled.")
-				return nil
+				return reported(ExitSecretDetected, ErrSecretBlocked)
+			}
+			messageStr = mes.FormatMessage(content, option)
+		}
+
+		printBeforeSend, err := rootConf.GetPrintBeforeSend()
+		if err != nil {
+			return fmt.Errorf("get print before send: %w", err)
+		}
+		if printBeforeSend {
+			d := &draft{content: content, option: option, channelName: channelName}
+			if err := checkMessage(ctx, d, mes, ed, guard); err != nil {
+				return err
 			}
+			messageStr, channelName = mes.FormatMessage(d.content, d.option), d.channelName
 		}
 
 		err = cl.SendMessage(messageStr, channelName)
 		if errors.Is(err, client.ErrEmptyMessage) {
-			return errors.New("empty message is not allowed")
+			return withExitCode(ExitInput, errors.New("empty message is not allowed"))
+		}
+		if errors.Is(err, client.ErrChannelNotFound) {
+			return withExitCode(ExitConfig, err)
 		}
 		if err != nil {
-			return fmt.Errorf("failed to send message: %w", err)
+			return withExitCode(ExitSend, fmt.Errorf("failed to send message: %w", err))
 		}
+		guard.sent()
 
 		return nil
 	}
@@ -130,40 +257,166 @@ func NewRoot[Client client.Client](rootCmd *RootBare, fileConf config.File, root
 	}
 }
 
-func checkMessage(message string) (ok bool, err error) {
+// editMessage opens the editor until the message passes the secret detection.
+// It returns ErrSendCanceled when the user aborts, and ErrSecretBlocked when the user gives up after a detection.
+func editMessage(ctx context.Context, ed editor.Editor, guard *secretGuard, initial string, channelName null.String) (string, error) {
+	help := []string{
+		"Write the message to send. Lines starting with \"#:\" are removed.",
+		"An empty message aborts sending.",
+		fmt.Sprintf("Channel: %s", cmp.Or(channelName.String, "(default channel of the webhook)")),
+	}
+
+	for {
+		mes, err := ed.Edit(ctx, initial, help)
+		if errors.Is(err, editor.ErrEmptyMessage) {
+			return "", fmt.Errorf("%w: aborting due to empty message", ErrSendCanceled)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to edit message: %w", err)
+		}
+
+		checked, ok, err := guard.check(ctx, mes, channelName.String)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return checked, nil
+		}
+
+		reopen, err := confirm("Re-open the editor? [y/n(any)]: ")
+		if err != nil {
+			return "", err
+		}
+		if !reopen {
+			return "", reported(ExitSecretDetected, ErrSecretBlocked)
+		}
+		initial = mes
+	}
+}
+
+// confirm asks a yes/no question on the controlling terminal.
+// Without a terminal, it is treated as "no".
+func confirm(prompt string) (ok bool, err error) {
 	g := goalie.New()
 	defer g.Collect(&err)
 
-	fmt.Printf(`========Message:========
-%s
-========================
-`, message)
-
-	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	t, err := tty.Open()
+	if errors.Is(err, tty.ErrNoTTY) {
+		return false, nil
+	}
 	if err != nil {
-		return false, fmt.Errorf("terminal make raw: %w", err)
+		return false, err
 	}
-	defer g.Guard(func() error {
-		if err := term.Restore(int(os.Stdin.Fd()), oldState); err != nil {
-			return fmt.Errorf("terminal restore: %w", err)
-		}
-		return nil
-	})
+	defer g.Guard(t.Close)
 
-	t := term.NewTerminal(os.Stdin, "")
-	t.SetPrompt("Send? [y/n(any)]: ")
-	l, err := t.ReadLine()
+	l, err := t.ReadLine(prompt)
 	if errors.Is(err, io.EOF) {
 		return false, nil
 	}
 	if err != nil {
-		return false, fmt.Errorf("terminal read line: %w", err)
+		return false, err
 	}
-	if strings.ToLower(l) != "y" {
-		return false, nil
+
+	return strings.ToLower(l) == "y", nil
+}
+
+// draft is a message being confirmed before sending.
+type draft struct {
+	content     message.Content
+	option      message.Option
+	channelName null.String
+}
+
+// checkMessage shows the message on the controlling terminal and asks whether to send it.
+// The terminal is opened directly, so it works even when the message came from a pipe.
+// d is updated when the user edits the message, switches the channel or toggles the code block.
+// It returns ErrSendCanceled unless the user chooses to send.
+func checkMessage(ctx context.Context, d *draft, mes message.Message, ed editor.Editor, guard *secretGuard) (err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	t, err := tty.Open()
+	if errors.Is(err, tty.ErrNoTTY) {
+		return withExitCode(ExitUsage, errors.New("--print-before-send needs a terminal to confirm the message, but there is no controlling terminal"))
+	}
+	if err != nil {
+		return err
+	}
+	defer g.Guard(t.Close)
+
+	r := render.NewRenderer(os.Getenv("NO_COLOR") == "")
+	for {
+		messageStr := mes.FormatMessage(d.content, d.option)
+		_, _ = fmt.Fprintf(t, `========Message:========
+%s
+========================
+Channel: %s
+`, r.Preview(messageStr), cmp.Or(d.channelName.String, "(default)"))
+
+		l, err := t.ReadLine("Send? [y]es / [n]o / [e]dit / [c]hannel / code [b]lock: ")
+		if errors.Is(err, io.EOF) {
+			return ErrSendCanceled
+		}
+		if err != nil {
+			return err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(l)) {
+		case "y", "yes":
+			return nil
+		case "e", "edit":
+			edited, err := editMessage(ctx, ed, guard, messageStr, d.channelName)
+			if err != nil {
+				return err
+			}
+			d.content, d.option.CodeBlock = message.Content{Body: edited}, false
+		case "c", "channel":
+			name, err := t.ReadLine("Channel name (empty for the default channel): ")
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			name = strings.TrimSpace(name)
+			// The channel may have stricter severities. When it blocks the message, the channel is not changed.
+			content, option, ok, err := checkContent(ctx, guard, mes, d.content, d.option, name)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			d.content, d.option, d.channelName = content, option, null.NewString(name, name != "")
+		case "b", "block":
+			d.option.CodeBlock = !d.option.CodeBlock
+		default:
+			return ErrSendCanceled
+		}
 	}
+}
+
+// checkContent checks the message without the code block, so that the lines of the findings match the written message.
+// When secrets are redacted, the caption and the code block are kept unless the caption itself was changed.
+func checkContent(ctx context.Context, guard *secretGuard, mes message.Message, content message.Content, option message.Option, channel string) (message.Content, message.Option, bool, error) {
+	plain := option
+	plain.CodeBlock = false
+	text := mes.FormatMessage(content, plain)
 
-	return true, nil
+	checked, ok, err := guard.check(ctx, text, channel)
+	if err != nil || !ok {
+		return content, option, ok, err
+	}
+	if checked == text {
+		return content, option, true, nil
+	}
+
+	// Secrets were redacted.
+	if content.Caption == "" {
+		return message.Content{Body: checked}, option, true, nil
+	}
+	if body, found := strings.CutPrefix(checked, content.Caption+"\n"); found {
+		return message.Content{Caption: content.Caption, Body: body}, option, true, nil
+	}
+	option.CodeBlock = false
+	return message.Content{Body: checked}, option, true, nil
 }
 
 var (
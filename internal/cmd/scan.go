@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/ikura-hamu/q-cli/internal/config"
+	"github.com/ikura-hamu/q-cli/internal/pkg/pipe"
+	"github.com/ikura-hamu/q-cli/internal/pkg/types"
+	"github.com/ikura-hamu/q-cli/internal/scan"
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/ras0q/goalie"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrSecretsFound = errors.New("secrets found")
+)
+
+type ScanBare struct {
+	*cobra.Command
+}
+
+func NewScanBare(rootCmd *Root) *ScanBare {
+	scanCmd := &cobra.Command{
+		Use: "scan [paths...]",
+		Example: `q scan .
+git diff | q scan
+q scan --staged`,
+		Short: "Scan files for secrets",
+		Long: `scanコマンドは、メッセージの送信前と同じシークレット検出をファイルに対して行います。
+ディレクトリを指定すると、.gitignoreで無視されるファイルを除いて再帰的に調べます。"-" は標準入力を表します。
+引数がない場合は、標準入力がパイプかファイルのリダイレクトなら標準入力を、そうでなければカレントディレクトリを調べます。
+--staged を指定すると、git diff --cached で追加された行を調べるので、pre-commit hookとして使えます。
+シークレットが見つかった場合は0以外の終了コードで終了します。`,
+	}
+
+	rootCmd.AddCommand(scanCmd)
+
+	return &ScanBare{
+		Command: scanCmd,
+	}
+}
+
+type Scan struct {
+	*cobra.Command
+}
+
+func NewScan(scanCmd *ScanBare, conf config.Scan, src scan.Source, secFactory types.Factory[secret.SecretDetector]) *Scan {
+	scanCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		staged, err := conf.GetStaged()
+		if err != nil {
+			return fmt.Errorf("get staged: %w", err)
+		}
+		output, err := conf.GetOutput()
+		if err != nil {
+			return fmt.Errorf("get output: %w", err)
+		}
+		if err := validateOutput(output); err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		if staged && len(args) > 0 {
+			return withExitCode(ExitUsage, errors.New("paths cannot be specified with --staged"))
+		}
+
+		sec, err := secFactory()
+		if err != nil {
+			return withExitCode(ExitConfig, fmt.Errorf("create secret detector: %w", err))
+		}
+
+		var targets []scan.Target
+		if staged {
+			targets, err = src.Staged(ctx)
+		} else {
+			if len(args) == 0 {
+				args = []string{"."}
+				if pipe.IsPipe(os.Stdin) {
+					args = []string{"-"}
+				}
+			}
+			targets, err = src.Files(ctx, args)
+		}
+		if err != nil {
+			return fmt.Errorf("read files: %w", err)
+		}
+
+		var results []scanFinding
+		for _, t := range targets {
+			err := detectTarget(ctx, sec, t)
+			findings, ok := secret.SecretDetected(err)
+			if !ok && err != nil {
+				return fmt.Errorf("failed to detect secret in %s: %w", t.Name, err)
+			}
+			for _, f := range findings {
+				f.Line = t.Line(f.Line)
+				results = append(results, scanFinding{File: t.Name, Finding: f})
+			}
+		}
+
+		if err := printScanFindings(os.Stdout, results, len(targets), output); err != nil {
+			return err
+		}
+		// Findings with the "warn" severity are printed but do not fail.
+		if slices.ContainsFunc(results, func(f scanFinding) bool { return f.Severity != secret.SeverityWarn }) {
+			return reported(ExitSecretDetected, ErrSecretsFound)
+		}
+		return nil
+	}
+
+	return &Scan{
+		Command: scanCmd.Command,
+	}
+}
+
+// detectTarget detects secrets in the target. Files and stdin are read in chunks, so that a large one fits in memory.
+func detectTarget(ctx context.Context, sec secret.SecretDetector, t scan.Target) (err error) {
+	if t.Open == nil {
+		return sec.Detect(ctx, t.Text)
+	}
+
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	r, err := t.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", t.Name, err)
+	}
+	defer g.Guard(r.Close)
+
+	return sec.DetectReader(ctx, r)
+}
+
+// scanFinding is a finding in a file.
+type scanFinding struct {
+	File string `json:"file"`
+	secret.Finding
+}
+
+// printScanFindings prints the findings in the form of "file:line:column", or as JSON when output is "json".
+func printScanFindings(w io.Writer, findings []scanFinding, files int, output string) error {
+	if output == outputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Findings []scanFinding `json:"findings"`
+		}{findings}); err != nil {
+			return fmt.Errorf("encode findings: %w", err)
+		}
+		return nil
+	}
+
+	for _, f := range findings {
+		_, _ = fmt.Fprintf(w, "%s:%d:%d: %s: %s (%s) %s fingerprint=%s\n", f.File, f.Line, f.Column, f.Severity, f.Rule(), f.Description, f.Excerpt, f.Fingerprint)
+	}
+	_, _ = fmt.Fprintf(w, "%d secret(s) found. %d file(s) scanned.\n", len(findings), files)
+	return nil
+}
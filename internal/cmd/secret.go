@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ikura-hamu/q-cli/internal/pkg/types"
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/spf13/cobra"
+)
+
+type Secret struct {
+	*cobra.Command
+}
+
+func NewSecret(rootCmd *Root) *Secret {
+	secretCmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Inspect the secret detection",
+		Long:  `secretコマンドは、送信前に行われるシークレット検出に関するサブコマンドをまとめたものです。`,
+	}
+
+	rootCmd.AddCommand(secretCmd)
+
+	return &Secret{
+		Command: secretCmd,
+	}
+}
+
+type SecretRules struct {
+	*cobra.Command
+}
+
+func NewSecretRules(secretCmd *Secret, secFactory types.Factory[secret.SecretDetector]) *SecretRules {
+	rulesCmd := &cobra.Command{
+		Use:   "rules",
+		Short: "List the secret detection rules",
+		Long: `rulesコマンドは、シークレット検出のルールと、それが有効かどうかを一覧表示します。
+ルールは設定ファイルの secret.ignore / secret.enable や、--secret-ignore / --secret-enable フラグで無効化・有効化できます。`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sec, err := secFactory()
+			if err != nil {
+				return fmt.Errorf("create secret detector: %w", err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "RULE\tACTIVE\tSEVERITY\tDESCRIPTION")
+			for _, c := range sec.Checkers() {
+				active := "no"
+				if c.Active {
+					active = "yes"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Key, active, c.Severity, c.Description)
+			}
+			return w.Flush()
+		},
+	}
+
+	secretCmd.AddCommand(rulesCmd)
+
+	return &SecretRules{
+		Command: rulesCmd,
+	}
+}
+
+type SecretAllow struct {
+	*cobra.Command
+}
+
+func NewSecretAllow(secretCmd *Secret, ignoreFileFactory types.Factory[secret.IgnoreFile]) *SecretAllow {
+	allowCmd := &cobra.Command{
+		Use:     "allow <fingerprint>...",
+		Example: "q secret allow 0123456789abcdef0123456789abcdef",
+		Short:   "Allow findings by their fingerprints",
+		Long: `allowコマンドは、検出結果のfingerprintを $HOME/.q-secretignore に追記し、以降その検出を無視します。
+fingerprintは、シークレットが検出されたときに表示されます。カレントディレクトリの .q-secretignore も読み込まれます。`,
+		Args: usageArgs(cobra.MinimumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, fp := range args {
+				if !secret.ValidFingerprint(fp) {
+					return fmt.Errorf("invalid fingerprint '%s'", fp)
+				}
+			}
+
+			f, err := ignoreFileFactory()
+			if err != nil {
+				return fmt.Errorf("open ignore file: %w", err)
+			}
+			if err := f.Add(args); err != nil {
+				return fmt.Errorf("add fingerprints: %w", err)
+			}
+
+			fmt.Printf("Added %d fingerprint(s) to %s\n", len(args), f.Path())
+			return nil
+		},
+	}
+
+	secretCmd.AddCommand(allowCmd)
+
+	return &SecretAllow{
+		Command: allowCmd,
+	}
+}
+
+type SecretAudit struct {
+	*cobra.Command
+}
+
+func NewSecretAudit(secretCmd *Secret, auditFactory types.Factory[secret.AuditLog]) *SecretAudit {
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Summarize the audit log of the secret detection",
+		Long: `auditコマンドは、シークレットが検出されたときの記録(監査ログ)を、ルールごとと週ごとに集計して表示します。
+監査ログは $HOME/.q-secret-audit.jsonl で、設定ファイルの secret.audit_file で変更できます。
+ルールごとの表は検出の数を、週ごとの表は送信の試行の数を、その結果(blocked, redacted, overridden, warned)ごとに数えます。
+監査ログにはシークレットそのものは記録されず、fingerprintだけが記録されます。
+fingerprintは、監査ログのパスに .key を付けたファイルのランダムな鍵でHMACにしたものです。`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			audit, err := auditFactory()
+			if err != nil {
+				return withExitCode(ExitConfig, fmt.Errorf("open audit log: %w", err))
+			}
+			events, err := audit.Events()
+			if err != nil {
+				return fmt.Errorf("read audit log: %w", err)
+			}
+
+			return printAuditSummary(os.Stdout, audit.Path(), events)
+		},
+	}
+
+	secretCmd.AddCommand(auditCmd)
+
+	return &SecretAudit{
+		Command: auditCmd,
+	}
+}
+
+var auditActions = []secret.AuditAction{secret.AuditBlocked, secret.AuditRedacted, secret.AuditOverridden, secret.AuditWarned}
+
+// auditCounts counts the events or findings by action.
+type auditCounts map[secret.AuditAction]int
+
+func (c auditCounts) row(name string) string {
+	row := name
+	total := 0
+	for _, a := range auditActions {
+		row += fmt.Sprintf("\t%d", c[a])
+		total += c[a]
+	}
+	return fmt.Sprintf("%s\t%d\n", row, total)
+}
+
+func printAuditSummary(w io.Writer, path string, events []secret.AuditEvent) error {
+	if len(events) == 0 {
+		_, _ = fmt.Fprintf(w, "No events in %s.\n", path)
+		return nil
+	}
+
+	rules := make(map[secret.CheckerKey]auditCounts)
+	weeks := make(map[string]auditCounts)
+	for _, e := range events {
+		for _, f := range e.Findings {
+			if rules[f.Rule] == nil {
+				rules[f.Rule] = make(auditCounts)
+			}
+			rules[f.Rule][e.Action]++
+		}
+		year, week := e.Time.Local().ISOWeek()
+		name := fmt.Sprintf("%d-W%02d", year, week)
+		if weeks[name] == nil {
+			weeks[name] = make(auditCounts)
+		}
+		weeks[name][e.Action]++
+	}
+
+	first := slices.MinFunc(events, func(a, b secret.AuditEvent) int { return a.Time.Compare(b.Time) })
+	last := slices.MaxFunc(events, func(a, b secret.AuditEvent) int { return a.Time.Compare(b.Time) })
+	_, _ = fmt.Fprintf(w, "%d event(s) in %s from %s to %s.\n\n", len(events), path,
+		first.Time.Local().Format(time.DateOnly), last.Time.Local().Format(time.DateOnly))
+
+	header := "\tBLOCKED\tREDACTED\tOVERRIDDEN\tWARNED\tTOTAL"
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "RULE"+header)
+	for _, key := range slices.Sorted(maps.Keys(rules)) {
+		_, _ = fmt.Fprint(tw, rules[key].row(string(key)))
+	}
+	_, _ = fmt.Fprintln(tw)
+	_, _ = fmt.Fprintln(tw, "WEEK"+header)
+	for _, name := range slices.Sorted(maps.Keys(weeks)) {
+		_, _ = fmt.Fprint(tw, weeks[name].row(name))
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("print audit summary: %w", err)
+	}
+	return nil
+}
@@ -20,4 +20,8 @@ type FileWriter interface {
 type FileReader interface {
 	FileNameGetter
 	Read() (ConfigValues, error)
+	// GetProfile returns the name of the selected profile. Empty means that no profile is selected.
+	GetProfile() (string, error)
+	// GetSources maps the dotted keys of the values to the path of the file they were read from.
+	GetSources() (map[string]string, error)
 }
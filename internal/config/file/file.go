@@ -4,23 +4,23 @@ import (
 	"fmt"
 
 	"github.com/ikura-hamu/q-cli/internal/config"
-	"github.com/spf13/viper"
 )
 
 type Writer struct {
-	v *viper.Viper
+	v *Viper
 }
 
 var _ config.FileWriter = (*Writer)(nil)
 
-func NewWriter(v *viper.Viper) *Writer {
+func NewWriter(v *Viper) *Writer {
 	return &Writer{
 		v: v,
 	}
 }
 
 func (w *Writer) GetUsedFilePath() (string, error) {
-	if err := w.v.ReadInConfig(); err != nil {
+	// Only the home config file is read, so that the values of the project config file are not written to it.
+	if err := w.v.Viper.ReadInConfig(); err != nil {
 		return "", fmt.Errorf("read config: %w", err)
 	}
 
@@ -48,14 +48,16 @@ func (w *Writer) Write(force bool, val config.ConfigValues) error {
 }
 
 type Reader struct {
-	v *viper.Viper
+	v       *Viper
+	profile config.Profile
 }
 
 var _ config.FileReader = (*Reader)(nil)
 
-func NewReader(v *viper.Viper) *Reader {
+func NewReader(v *Viper, profile config.Profile) *Reader {
 	return &Reader{
-		v: v,
+		v:       v,
+		profile: profile,
 	}
 }
 
@@ -76,3 +78,20 @@ func (r *Reader) GetUsedFilePath() (string, error) {
 
 	return r.v.ConfigFileUsed(), nil
 }
+
+func (r *Reader) GetProfile() (string, error) {
+	if err := r.v.ReadInConfig(); err != nil {
+		return "", fmt.Errorf("read config: %w", err)
+	}
+
+	name, _, err := selectProfile(r.v, r.profile)
+	return name, err
+}
+
+func (r *Reader) GetSources() (map[string]string, error) {
+	if err := r.v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	return r.v.Sources(), nil
+}
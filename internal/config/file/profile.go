@@ -0,0 +1,65 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ikura-hamu/q-cli/internal/config"
+)
+
+const (
+	configKeyProfiles       = "profiles"
+	configKeyDefaultProfile = "default_profile"
+	envProfile              = "Q_PROFILE"
+)
+
+// profile is a set of webhook settings. Empty values fall back to the top-level settings.
+type profile struct {
+	WebhookHost   string            `mapstructure:"webhook_host"`
+	WebhookID     string            `mapstructure:"webhook_id"`
+	WebhookSecret string            `mapstructure:"webhook_secret"`
+	Channels      map[string]string `mapstructure:"channels"`
+}
+
+func readProfiles(v *Viper) (map[string]profile, error) {
+	var profiles map[string]profile
+	if err := v.UnmarshalKey(configKeyProfiles, &profiles); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", configKeyProfiles, err)
+	}
+	return profiles, nil
+}
+
+// selectProfile returns the name of the profile selected by the flag, $Q_PROFILE or default_profile, in this order.
+// Empty means that no profile is selected and the top-level settings are used.
+// Profile names are case-insensitive, because the config file does not keep the case of map keys.
+func selectProfile(v *Viper, conf config.Profile) (string, profile, error) {
+	name := ""
+	if conf != nil {
+		p, err := conf.GetProfile()
+		if err != nil {
+			return "", profile{}, fmt.Errorf("get profile: %w", err)
+		}
+		name = p.ValueOrZero()
+	}
+	if name == "" {
+		name = os.Getenv(envProfile)
+	}
+	if name == "" {
+		name = v.GetString(configKeyDefaultProfile)
+	}
+	if name == "" {
+		return "", profile{}, nil
+	}
+
+	profiles, err := readProfiles(v)
+	if err != nil {
+		return "", profile{}, err
+	}
+	name = strings.ToLower(name)
+	p, ok := profiles[name]
+	if !ok {
+		return "", profile{}, fmt.Errorf("profile '%s' is not defined in the config file", name)
+	}
+	return name, p, nil
+}
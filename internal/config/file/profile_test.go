@@ -0,0 +1,192 @@
+package file
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null/v6"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flagProfile null.String
+
+func (p flagProfile) GetProfile() (null.String, error) {
+	return null.String(p), nil
+}
+
+// newTestViper returns a Viper reading the config file with the content, like NewViper but without the home directory.
+func newTestViper(t *testing.T, content string) *Viper {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".q-cli.yaml")
+	writeFile(t, path, content)
+
+	vv := viper.New()
+	vv.SetConfigFile(path)
+	vv.AutomaticEnv()
+	vv.SetEnvPrefix("Q")
+	v := &Viper{Viper: vv}
+	require.NoError(t, v.ReadInConfig())
+	return v
+}
+
+func Test_selectProfile(t *testing.T) {
+	const content = `
+webhook_id: top
+default_profile: work
+profiles:
+  work:
+    webhook_id: work-id
+  hobby:
+    webhook_id: hobby-id
+  ci:
+    webhook_id: ci-id
+`
+
+	test := map[string]struct {
+		content string
+		flag    null.String
+		env     string
+		want    string
+		wantID  string
+		wantErr bool
+	}{
+		"フラグが最優先": {
+			content: content,
+			flag:    null.StringFrom("hobby"),
+			env:     "ci",
+			want:    "hobby",
+			wantID:  "hobby-id",
+		},
+		"フラグがなければQ_PROFILE": {
+			content: content,
+			env:     "ci",
+			want:    "ci",
+			wantID:  "ci-id",
+		},
+		"どちらもなければdefault_profile": {
+			content: content,
+			want:    "work",
+			wantID:  "work-id",
+		},
+		"大文字小文字を区別しない": {
+			content: content,
+			flag:    null.StringFrom("Hobby"),
+			want:    "hobby",
+			wantID:  "hobby-id",
+		},
+		"プロファイルを選ばない": {
+			content: "webhook_id: top\n",
+			want:    "",
+		},
+		"定義されていないプロファイル": {
+			content: content,
+			flag:    null.StringFrom("unknown"),
+			wantErr: true,
+		},
+		"定義されていないdefault_profile": {
+			content: "default_profile: unknown\n",
+			wantErr: true,
+		},
+	}
+
+	for description, tt := range test {
+		t.Run(description, func(t *testing.T) {
+			t.Setenv(envProfile, tt.env)
+			v := newTestViper(t, tt.content)
+
+			name, p, err := selectProfile(v, flagProfile(tt.flag))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, name)
+			assert.Equal(t, tt.wantID, p.WebhookID)
+		})
+	}
+}
+
+func TestWebhook_setting(t *testing.T) {
+	const content = `
+webhook_host: top.example.com
+webhook_id: top-id
+webhook_secret: top-secret
+profiles:
+  work:
+    webhook_id: work-id
+`
+
+	test := map[string]struct {
+		env        map[string]string
+		wantHost   string
+		wantID     string
+		wantSecret string
+	}{
+		"プロファイルの次にトップレベル": {
+			wantHost:   "top.example.com",
+			wantID:     "work-id",
+			wantSecret: "top-secret",
+		},
+		"環境変数がプロファイルより優先": {
+			env:        map[string]string{"Q_WEBHOOK_ID": "env-id", "Q_WEBHOOK_SECRET": "env-secret"},
+			wantHost:   "top.example.com",
+			wantID:     "env-id",
+			wantSecret: "env-secret",
+		},
+	}
+
+	for description, tt := range test {
+		t.Run(description, func(t *testing.T) {
+			t.Setenv(envProfile, "")
+			for _, key := range []string{"Q_WEBHOOK_HOST", "Q_WEBHOOK_ID", "Q_WEBHOOK_SECRET"} {
+				t.Setenv(key, tt.env[key])
+			}
+			v := newTestViper(t, content)
+
+			w, err := NewWebhook(v, flagProfile(null.StringFrom("work")))
+			require.NoError(t, err)
+
+			host, err := w.GetHostName()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHost, host)
+			id, err := w.GetWebhookID()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantID, id)
+			secret, err := w.GetSecret()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSecret, secret)
+		})
+	}
+}
+
+func TestWebhook_GetChannels(t *testing.T) {
+	const (
+		top     = "00000000-0000-0000-0000-000000000001"
+		shared  = "00000000-0000-0000-0000-000000000002"
+		profile = "00000000-0000-0000-0000-000000000003"
+	)
+	t.Setenv(envProfile, "")
+	v := newTestViper(t, `
+channels:
+  top: `+top+`
+  shared: `+top+`
+profiles:
+  work:
+    channels:
+      shared: `+shared+`
+      work: `+profile+`
+`)
+
+	w, err := NewWebhook(v, flagProfile(null.StringFrom("work")))
+	require.NoError(t, err)
+	channels, err := w.GetChannels()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uuid.UUID{
+		"top":    uuid.MustParse(top),
+		"shared": uuid.MustParse(shared),
+		"work":   uuid.MustParse(profile),
+	}, channels)
+}
@@ -0,0 +1,173 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/ikura-hamu/q-cli/internal/config"
+	"github.com/spf13/viper"
+)
+
+const (
+	configKeySecretIgnore          = "secret.ignore"
+	configKeySecretEnable          = "secret.enable"
+	configKeySecretRules           = "secret.rules"
+	configKeySecretGitleaksConfigs = "secret.gitleaks_configs"
+	configKeySecretGitSecrets      = "secret.git_secrets"
+	configKeySecretEntropy         = "secret.entropy"
+	configKeySecretAllowlist       = "secret.allowlist"
+	configKeySecretKnownFiles      = "secret.known_secret_files"
+	configKeySecretPII             = "secret.pii"
+	configKeySecretSeverity        = "secret.severity"
+	configKeySecretChannels        = "secret.channels"
+	configKeySecretAuditFile       = "secret.audit_file"
+)
+
+type Secret struct {
+	v *Viper
+}
+
+var _ config.Secret = (*Secret)(nil)
+
+// NewSecret reads the secret detection settings.
+// Unlike the webhook settings, a missing config file is not an error, because secret detection has defaults.
+func NewSecret(v *Viper) (*Secret, error) {
+	err := v.ReadInConfig()
+	if err != nil && !errors.As(err, &viper.ConfigFileNotFoundError{}) {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	return &Secret{
+		v: v,
+	}, nil
+}
+
+func NewSecretFactory(v *Viper) func() (config.Secret, error) {
+	return func() (config.Secret, error) {
+		return NewSecret(v)
+	}
+}
+
+func (s *Secret) GetIgnore() ([]string, error) {
+	return s.v.GetStringSlice(configKeySecretIgnore), nil
+}
+
+func (s *Secret) GetEnable() ([]string, error) {
+	return s.v.GetStringSlice(configKeySecretEnable), nil
+}
+
+type secretRule struct {
+	ID          string   `mapstructure:"id"`
+	Description string   `mapstructure:"description"`
+	Regex       string   `mapstructure:"regex"`
+	SecretGroup int      `mapstructure:"secret_group"`
+	Keywords    []string `mapstructure:"keywords"`
+	Allowlist   []string `mapstructure:"allowlist"`
+	Severity    string   `mapstructure:"severity"`
+}
+
+func (s *Secret) GetRules() ([]config.SecretRule, error) {
+	var rules []secretRule
+	if err := s.v.UnmarshalKey(configKeySecretRules, &rules); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", configKeySecretRules, err)
+	}
+
+	res := make([]config.SecretRule, 0, len(rules))
+	for _, r := range rules {
+		res = append(res, config.SecretRule(r))
+	}
+	return res, nil
+}
+
+func (s *Secret) GetGitleaksConfigs() ([]string, error) {
+	return s.v.GetStringSlice(configKeySecretGitleaksConfigs), nil
+}
+
+func (s *Secret) GetGitSecrets() (bool, error) {
+	return s.v.GetBool(configKeySecretGitSecrets), nil
+}
+
+type secretEntropy struct {
+	MinLength       int     `mapstructure:"min_length"`
+	HexThreshold    float64 `mapstructure:"hex_threshold"`
+	Base64Threshold float64 `mapstructure:"base64_threshold"`
+}
+
+func (s *Secret) GetEntropy() (config.SecretEntropy, error) {
+	var e secretEntropy
+	if err := s.v.UnmarshalKey(configKeySecretEntropy, &e); err != nil {
+		return config.SecretEntropy{}, fmt.Errorf("invalid %s: %w", configKeySecretEntropy, err)
+	}
+	return config.SecretEntropy(e), nil
+}
+
+func (s *Secret) GetAllowlist() ([]string, error) {
+	return s.v.GetStringSlice(configKeySecretAllowlist), nil
+}
+
+// secretConfigKeys are the config keys whose values are secrets.
+var secretConfigKeys = []string{configKeyWebhookSecret}
+
+// GetKnownSecrets returns the secrets of every profile too, not only the selected one.
+func (s *Secret) GetKnownSecrets() ([]string, error) {
+	var values []string
+	for _, key := range secretConfigKeys {
+		if v := s.v.GetString(key); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	profiles, err := readProfiles(s.v)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		if v := profiles[name].WebhookSecret; v != "" {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+func (s *Secret) GetKnownSecretFiles() ([]string, error) {
+	return s.v.GetStringSlice(configKeySecretKnownFiles), nil
+}
+
+type secretPII struct {
+	Rules              map[string]string `mapstructure:"rules"`
+	InstitutionIDRegex string            `mapstructure:"institution_id_regex"`
+}
+
+func (s *Secret) GetPII() (config.SecretPII, error) {
+	var p secretPII
+	if err := s.v.UnmarshalKey(configKeySecretPII, &p); err != nil {
+		return config.SecretPII{}, fmt.Errorf("invalid %s: %w", configKeySecretPII, err)
+	}
+	return config.SecretPII(p), nil
+}
+
+func (s *Secret) GetSeverities() (map[string]string, error) {
+	return s.v.GetStringMapString(configKeySecretSeverity), nil
+}
+
+type secretChannel struct {
+	Severity map[string]string `mapstructure:"severity"`
+}
+
+func (s *Secret) GetChannelSeverities() (map[string]map[string]string, error) {
+	var channels map[string]secretChannel
+	if err := s.v.UnmarshalKey(configKeySecretChannels, &channels); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", configKeySecretChannels, err)
+	}
+
+	res := make(map[string]map[string]string, len(channels))
+	for name, c := range channels {
+		res[name] = c.Severity
+	}
+	return res, nil
+}
+
+func (s *Secret) GetAuditFile() (string, error) {
+	return s.v.GetString(configKeySecretAuditFile), nil
+}
@@ -0,0 +1,42 @@
+package file
+
+import (
+	"fmt"
+
+	"github.com/ikura-hamu/q-cli/internal/config"
+)
+
+const (
+	configKeyTemplates = "templates"
+)
+
+type Template struct {
+	v *Viper
+}
+
+var _ config.Template = (*Template)(nil)
+
+func NewTemplate(v *Viper) (*Template, error) {
+	err := v.ReadInConfig()
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	return &Template{
+		v: v,
+	}, nil
+}
+
+func NewTemplateFactory(v *Viper) func() (config.Template, error) {
+	return func() (config.Template, error) {
+		return NewTemplate(v)
+	}
+}
+
+func (t *Template) GetTemplate(name string) (string, error) {
+	templates := t.v.GetStringMapString(configKeyTemplates)
+	tmpl, ok := templates[name]
+	if !ok {
+		return "", fmt.Errorf("template '%s' is not in the configuration", name)
+	}
+	return tmpl, nil
+}
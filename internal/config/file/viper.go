@@ -1,14 +1,45 @@
 package file
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/ikura-hamu/q-cli/internal/config"
 	"github.com/spf13/viper"
 )
 
-func NewViper(conf config.File) (*viper.Viper, error) {
+// ProjectConfigFileName is the name of the config file of a project.
+// It is searched for from the current directory up to the root of the git repository.
+const ProjectConfigFileName = ".q-cli.yaml"
+
+// configKeyAllowProjectSecrets allows project config files to have the home-only settings. It is only read from the home config file,
+// so that a project cannot allow itself.
+const configKeyAllowProjectSecrets = "allow_project_secrets"
+
+// homeOnlyWebhookKeys are the webhook settings a project config file must not have, at the top level or in a profile.
+// Together with the secret, the host and the ID decide where the messages signed with it are sent.
+var homeOnlyWebhookKeys = []string{configKeyWebhookHost, configKeyWebhookID, configKeyWebhookSecret}
+
+// homeOnlySection is the section a project config file must not have, so that a committed file cannot weaken secret detection.
+const homeOnlySection = "secret"
+
+// Viper reads the home config file and merges the project config file over it.
+// The credentials are kept in the home config file, because a project config file may be committed.
+type Viper struct {
+	*viper.Viper
+	// projectFile is the path of the project config file. Empty means there is none.
+	projectFile string
+	// sources maps the keys read by ReadInConfig to the file they were read from.
+	sources map[string]string
+}
+
+func NewViper(conf config.File) (*Viper, error) {
 	cfgFile, err := conf.GetFilePath()
 	if err != nil {
 		return nil, fmt.Errorf("get config file path: %w", err)
@@ -19,6 +50,7 @@ func NewViper(conf config.File) (*viper.Viper, error) {
 	v.AutomaticEnv()
 	v.SetEnvPrefix("Q")
 
+	projectFile := ""
 	if cfgFile.Valid {
 		v.SetConfigFile(cfgFile.ValueOrZero())
 	} else {
@@ -29,7 +61,110 @@ func NewViper(conf config.File) (*viper.Viper, error) {
 
 		// Search config in home directory with name ".q-cli" (without extension).
 		v.AddConfigPath(home)
+
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("get current directory: %w", err)
+		}
+		projectFile, err = findProjectConfig(wd, home)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Viper{Viper: v, projectFile: projectFile}, nil
+}
+
+// findProjectConfig returns the nearest project config file in dir or its parents up to the root of the git repository.
+// Outside of a git repository, only dir is searched. The home directory is skipped, because its config file is the home config file.
+func findProjectConfig(dir string, home string) (string, error) {
+	root := dir
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			root = d
+			break
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("find git repository: %w", err)
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	for d := dir; ; d = filepath.Dir(d) {
+		if d != home {
+			path := filepath.Join(d, ProjectConfigFileName)
+			info, err := os.Stat(path)
+			if err == nil && info.Mode().IsRegular() {
+				return path, nil
+			}
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return "", fmt.Errorf("find project config: %w", err)
+			}
+		}
+		if d == root || filepath.Dir(d) == d {
+			return "", nil
+		}
+	}
+}
+
+// ReadInConfig reads the home config file and merges the project config file over it.
+// A missing home config file is not an error if there is a project config file.
+// It fails if the project config file has a home-only setting, unless allow_project_secrets is true in the home config file.
+func (v *Viper) ReadInConfig() error {
+	err := v.Viper.ReadInConfig()
+	homeNotFound := errors.As(err, &viper.ConfigFileNotFoundError{})
+	if err != nil && !homeNotFound {
+		return err
+	}
+
+	v.sources = make(map[string]string)
+	if !homeNotFound {
+		for _, key := range v.Viper.AllKeys() {
+			v.sources[key] = v.Viper.ConfigFileUsed()
+		}
+	}
+	if v.projectFile == "" {
+		return err
+	}
+
+	pv := viper.New()
+	pv.SetConfigType("yaml")
+	pv.SetConfigFile(v.projectFile)
+	if err := pv.ReadInConfig(); err != nil {
+		return fmt.Errorf("read project config: %w", err)
 	}
+	if !v.Viper.GetBool(configKeyAllowProjectSecrets) {
+		for _, key := range pv.AllKeys() {
+			if isHomeOnlyConfigKey(key) {
+				return fmt.Errorf("%s: '%s' must not be in a project config file, which may be committed. "+
+					"Move it to the config file in your home directory, or set '%s: true' there", v.projectFile, key, configKeyAllowProjectSecrets)
+			}
+		}
+	}
+
+	if err := v.Viper.MergeConfigMap(pv.AllSettings()); err != nil {
+		return fmt.Errorf("merge project config: %w", err)
+	}
+	for _, key := range pv.AllKeys() {
+		v.sources[key] = v.projectFile
+	}
+	return nil
+}
+
+// isHomeOnlyConfigKey reports whether the key may only be set in the home config file:
+// the webhook settings at the top level or in a profile, the secret detection settings, and allow_project_secrets itself.
+func isHomeOnlyConfigKey(key string) bool {
+	if key == configKeyAllowProjectSecrets || key == homeOnlySection || strings.HasPrefix(key, homeOnlySection+".") {
+		return true
+	}
+	if rest, ok := strings.CutPrefix(key, configKeyProfiles+"."); ok {
+		_, key, _ = strings.Cut(rest, ".")
+	}
+	return slices.Contains(homeOnlyWebhookKeys, key)
+}
 
-	return v, nil
+// Sources maps the keys read by the last ReadInConfig to the path of the file they were read from.
+func (v *Viper) Sources() map[string]string {
+	return maps.Clone(v.sources)
 }
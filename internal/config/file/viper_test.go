@@ -0,0 +1,265 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func Test_findProjectConfig(t *testing.T) {
+	test := map[string]struct {
+		files []string
+		dirs  []string
+		dir   string
+		home  string
+		want  string
+	}{
+		"カレントディレクトリにある": {
+			files: []string{"repo/.git/HEAD", "repo/sub/.q-cli.yaml"},
+			dir:   "repo/sub",
+			want:  "repo/sub/.q-cli.yaml",
+		},
+		"リポジトリのルートまでさかのぼる": {
+			files: []string{"repo/.git/HEAD", "repo/.q-cli.yaml"},
+			dirs:  []string{"repo/sub/dir"},
+			dir:   "repo/sub/dir",
+			want:  "repo/.q-cli.yaml",
+		},
+		"最も近いものを使う": {
+			files: []string{"repo/.git/HEAD", "repo/.q-cli.yaml", "repo/sub/.q-cli.yaml"},
+			dirs:  []string{"repo/sub/dir"},
+			dir:   "repo/sub/dir",
+			want:  "repo/sub/.q-cli.yaml",
+		},
+		"リポジトリの外は探さない": {
+			files: []string{".q-cli.yaml", "repo/.git/HEAD"},
+			dirs:  []string{"repo/sub"},
+			dir:   "repo/sub",
+		},
+		"サブモジュールの.gitファイルもルート": {
+			files: []string{"repo/.git/HEAD", "repo/.q-cli.yaml", "repo/mod/.git"},
+			dirs:  []string{"repo/mod/sub"},
+			dir:   "repo/mod/sub",
+		},
+		"リポジトリの外ではカレントディレクトリだけ": {
+			files: []string{"dir/.q-cli.yaml"},
+			dirs:  []string{"dir/sub"},
+			dir:   "dir/sub",
+		},
+		"ホームディレクトリの設定ファイルは使わない": {
+			files: []string{"home/.git/HEAD", "home/.q-cli.yaml"},
+			dirs:  []string{"home/sub"},
+			dir:   "home/sub",
+			home:  "home",
+		},
+		"ディレクトリは設定ファイルではない": {
+			files: []string{"repo/.git/HEAD"},
+			dirs:  []string{"repo/.q-cli.yaml"},
+			dir:   "repo",
+		},
+	}
+
+	for description, tt := range test {
+		t.Run(description, func(t *testing.T) {
+			root := t.TempDir()
+			for _, f := range tt.files {
+				writeFile(t, filepath.Join(root, f), "")
+			}
+			for _, d := range tt.dirs {
+				require.NoError(t, os.MkdirAll(filepath.Join(root, d), 0o755))
+			}
+			home := filepath.Join(root, "not_home")
+			if tt.home != "" {
+				home = filepath.Join(root, tt.home)
+			}
+
+			got, err := findProjectConfig(filepath.Join(root, tt.dir), home)
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Empty(t, got)
+			} else {
+				assert.Equal(t, filepath.Join(root, tt.want), got)
+			}
+		})
+	}
+}
+
+func Test_isHomeOnlyConfigKey(t *testing.T) {
+	test := map[string]bool{
+		"webhook_host":                   true,
+		"webhook_id":                     true,
+		"webhook_secret":                 true,
+		"allow_project_secrets":          true,
+		"secret":                         true,
+		"secret.ignore":                  true,
+		"secret.severity.*":              true,
+		"secret.rules":                   true,
+		"profiles.work.webhook_host":     true,
+		"profiles.work.webhook_id":       true,
+		"profiles.work.webhook_secret":   true,
+		"channels.dev":                   false,
+		"templates.release":              false,
+		"default_profile":                false,
+		"profiles.work.channels.dev":     false,
+		"profiles.webhook_secret":        false,
+		"secrets":                        false,
+		"templates.webhook_secret":       false,
+		"profiles.work.channels.secret":  false,
+		"profiles.work.webhook_secret.x": false,
+	}
+
+	for key, want := range test {
+		t.Run(key, func(t *testing.T) {
+			assert.Equal(t, want, isHomeOnlyConfigKey(key))
+		})
+	}
+}
+
+func TestViper_ReadInConfig(t *testing.T) {
+	const homeConfig = `webhook_host: q.example.com
+webhook_id: home-id
+webhook_secret: home-secret
+channels:
+  home: home-channel
+`
+
+	test := map[string]struct {
+		home        string
+		project     string
+		want        map[string]any
+		wantSources map[string]string
+		wantErr     bool
+	}{
+		"プロジェクトの設定をマージする": {
+			home:    homeConfig,
+			project: "channels:\n  dev: dev-channel\ntemplates:\n  release: release\n",
+			want: map[string]any{
+				"webhook_host":      "q.example.com",
+				"webhook_id":        "home-id",
+				"webhook_secret":    "home-secret",
+				"channels.home":     "home-channel",
+				"channels.dev":      "dev-channel",
+				"templates.release": "release",
+			},
+			wantSources: map[string]string{
+				"webhook_host":      "home",
+				"webhook_id":        "home",
+				"webhook_secret":    "home",
+				"channels.home":     "home",
+				"channels.dev":      "project",
+				"templates.release": "project",
+			},
+		},
+		"プロジェクトの設定が優先される": {
+			home:    homeConfig + "templates:\n  release: home\n",
+			project: "templates:\n  release: project\n",
+			want:    map[string]any{"templates.release": "project"},
+			wantSources: map[string]string{
+				"templates.release": "project",
+				"channels.home":     "home",
+			},
+		},
+		"プロジェクトの設定ファイルがない": {
+			home:        homeConfig,
+			want:        map[string]any{"webhook_id": "home-id"},
+			wantSources: map[string]string{"webhook_id": "home"},
+		},
+		"ホームの設定ファイルがない": {
+			project:     "channels:\n  dev: dev-channel\n",
+			want:        map[string]any{"channels.dev": "dev-channel"},
+			wantSources: map[string]string{"channels.dev": "project"},
+		},
+		"プロジェクトのシークレットは拒否する": {
+			home:    homeConfig,
+			project: "webhook_secret: leaked\n",
+			wantErr: true,
+		},
+		"プロジェクトのホストは拒否する": {
+			home:    homeConfig,
+			project: "webhook_host: attacker.example.com\n",
+			wantErr: true,
+		},
+		"プロジェクトのプロファイルのIDは拒否する": {
+			home:    homeConfig,
+			project: "profiles:\n  work:\n    webhook_id: attacker-id\n",
+			wantErr: true,
+		},
+		"プロジェクトのシークレット検出の設定は拒否する": {
+			home:    homeConfig,
+			project: "secret:\n  severity:\n    \"*\": warn\n",
+			wantErr: true,
+		},
+		"プロジェクトは自分を許可できない": {
+			home:    homeConfig,
+			project: "allow_project_secrets: true\nwebhook_secret: leaked\n",
+			wantErr: true,
+		},
+		"ホームで許可されていればマージする": {
+			home:    homeConfig + "allow_project_secrets: true\n",
+			project: "webhook_secret: project-secret\nsecret:\n  ignore: [entropy]\n",
+			want: map[string]any{
+				"webhook_host":   "q.example.com",
+				"webhook_secret": "project-secret",
+				"secret.ignore":  []any{"entropy"},
+			},
+			wantSources: map[string]string{
+				"webhook_secret": "project",
+				"secret.ignore":  "project",
+			},
+		},
+		"ホームの設定ファイルがなくてもシークレットは拒否する": {
+			project: "webhook_secret: leaked\n",
+			wantErr: true,
+		},
+	}
+
+	for description, tt := range test {
+		t.Run(description, func(t *testing.T) {
+			dir := t.TempDir()
+			files := map[string]string{
+				"home":    filepath.Join(dir, "home", ".q-cli.yaml"),
+				"project": filepath.Join(dir, "project", ProjectConfigFileName),
+			}
+			require.NoError(t, os.MkdirAll(filepath.Dir(files["home"]), 0o755))
+			if tt.home != "" {
+				writeFile(t, files["home"], tt.home)
+			}
+			projectFile := ""
+			if tt.project != "" {
+				writeFile(t, files["project"], tt.project)
+				projectFile = files["project"]
+			}
+
+			vv := viper.New()
+			vv.SetConfigType("yaml")
+			vv.SetConfigName(".q-cli")
+			vv.AddConfigPath(filepath.Dir(files["home"]))
+			v := &Viper{Viper: vv, projectFile: projectFile}
+
+			err := v.ReadInConfig()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			for key, want := range tt.want {
+				assert.Equal(t, want, v.Get(key), key)
+			}
+			sources := v.Sources()
+			for key, want := range tt.wantSources {
+				assert.Equal(t, files[want], sources[key], key)
+			}
+		})
+	}
+}
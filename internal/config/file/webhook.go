@@ -1,11 +1,14 @@
 package file
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"os"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/ikura-hamu/q-cli/internal/config"
-	"github.com/spf13/viper"
 )
 
 const (
@@ -15,56 +18,83 @@ const (
 	configKeyChannels      = "channels"
 )
 
+// Webhook reads the webhook settings of the selected profile.
+// The environment variables take precedence over the profile,
+// and the settings the profile does not have are read from the top level of the config file.
+// The channels of the profile are added to those of the top level, replacing the ones of the same name.
 type Webhook struct {
-	v *viper.Viper
+	v           *Viper
+	profileName string
+	profile     profile
 }
 
 var _ config.Webhook = (*Webhook)(nil)
 
-func NewWebhook(v *viper.Viper) (*Webhook, error) {
+func NewWebhook(v *Viper, conf config.Profile) (*Webhook, error) {
 	err := v.ReadInConfig()
 	if err != nil {
 		return nil, fmt.Errorf("read config: %w", err)
 	}
+	name, p, err := selectProfile(v, conf)
+	if err != nil {
+		return nil, err
+	}
 	return &Webhook{
-		v: v,
+		v:           v,
+		profileName: name,
+		profile:     p,
 	}, nil
 }
 
-func NewWebhookFactory(v *viper.Viper) func() (config.Webhook, error) {
+func NewWebhookFactory(v *Viper, conf config.Profile) func() (config.Webhook, error) {
 	return func() (config.Webhook, error) {
-		return NewWebhook(v)
+		return NewWebhook(v, conf)
 	}
 }
 
+// notSet returns the error for a missing setting, naming the profile if one is selected.
+func (w *Webhook) notSet(name string) error {
+	if w.profileName != "" {
+		return fmt.Errorf("%s is not set in profile '%s'", name, w.profileName)
+	}
+	return fmt.Errorf("%s is not set", name)
+}
+
+// setting returns the value of the key from $Q_<KEY>, the profile or the top level of the config file, in this order.
+func (w *Webhook) setting(key string, profileValue string) string {
+	return cmp.Or(os.Getenv("Q_"+strings.ToUpper(key)), profileValue, w.v.GetString(key))
+}
+
 func (w *Webhook) GetWebhookID() (string, error) {
-	v := w.v.GetString(configKeyWebhookID)
+	v := w.setting(configKeyWebhookID, w.profile.WebhookID)
 	if v == "" {
-		return "", fmt.Errorf("webhook ID is not set")
+		return "", w.notSet("webhook ID")
 	}
 	return v, nil
 }
 
 func (w *Webhook) GetHostName() (string, error) {
-	v := w.v.GetString(configKeyWebhookHost)
+	v := w.setting(configKeyWebhookHost, w.profile.WebhookHost)
 	if v == "" {
-		return "", fmt.Errorf("webhook host is not set")
+		return "", w.notSet("webhook host")
 	}
 	return v, nil
 }
 
 func (w *Webhook) GetSecret() (string, error) {
-	v := w.v.GetString(configKeyWebhookSecret)
+	v := w.setting(configKeyWebhookSecret, w.profile.WebhookSecret)
 	if v == "" {
-		return "", fmt.Errorf("webhook secret is not set")
+		return "", w.notSet("webhook secret")
 	}
 	return v, nil
 }
 
 func (w *Webhook) GetChannels() (map[string]uuid.UUID, error) {
-	v := w.v.GetStringMapString(configKeyChannels)
+	v := make(map[string]string)
+	maps.Copy(v, w.v.GetStringMapString(configKeyChannels))
+	maps.Copy(v, w.profile.Channels)
 	if len(v) == 0 {
-		return nil, fmt.Errorf("webhook channels are not set")
+		return nil, w.notSet("webhook channels")
 	}
 
 	channels := make(map[string]uuid.UUID, len(v))
@@ -0,0 +1,26 @@
+package flag
+
+import (
+	"github.com/guregu/null/v6"
+	"github.com/ikura-hamu/q-cli/internal/config"
+	"github.com/spf13/pflag"
+)
+
+type Profile struct {
+	profile string
+}
+
+var _ config.Profile = (*Profile)(nil)
+
+func NewProfile(flagSet *pflag.FlagSet) *Profile {
+	p := &Profile{}
+	flagSet.StringVarP(&p.profile, "profile", "P", "", "Profile of the config file to use. (default is $Q_PROFILE or default_profile in the config file)")
+	return p
+}
+
+func (p *Profile) GetProfile() (null.String, error) {
+	if p.profile == "" {
+		return null.String{}, nil
+	}
+	return null.StringFrom(p.profile), nil
+}
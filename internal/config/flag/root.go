@@ -1,6 +1,8 @@
 package flag
 
 import (
+	"time"
+
 	"github.com/guregu/null/v6"
 	"github.com/ikura-hamu/q-cli/internal/config"
 	"github.com/spf13/pflag"
@@ -12,10 +14,22 @@ type Root struct {
 	codeBlockLang   string
 	channelName     string
 	printBeforeSend bool
+	maxBytes        int64
+	headLines       int
+	tailLines       int
+	withStdin       bool
+	stdinTimeout    time.Duration
+	edit            bool
+	template        string
+	redact          bool
+	output          string
+	allowSecrets    []string
 }
 
 var _ config.Root = (*Root)(nil)
 
+const defaultMaxBytes = 1 << 20
+
 func NewRoot(flagSet *pflag.FlagSet) *Root {
 	r := &Root{}
 	flagSet.BoolVarP(&r.version, "version", "v", false, "Print version information and exit.")
@@ -23,6 +37,16 @@ func NewRoot(flagSet *pflag.FlagSet) *Root {
 	flagSet.StringVarP(&r.codeBlockLang, "lang", "l", "", "Specify the language for the code block. Used only when --code-block is set.")
 	flagSet.StringVarP(&r.channelName, "channel", "C", "", "Specify the channel name to send the message to. If not specified, the default channel will be used.")
 	flagSet.BoolVarP(&r.printBeforeSend, "print-before-send", "p", false, "Print the message to be sent before sending it.")
+	flagSet.Int64Var(&r.maxBytes, "max-bytes", defaultMaxBytes, "Maximum size in bytes of the message read from stdin. 0 means unlimited.")
+	flagSet.IntVar(&r.headLines, "head", 0, "Send only the first N lines of stdin.")
+	flagSet.IntVar(&r.tailLines, "tail", 0, "Send only the last N lines of stdin.")
+	flagSet.BoolVar(&r.withStdin, "with-stdin", false, "Use the arguments as a caption and stdin as the body. Enabled automatically when stdin is a pipe.")
+	flagSet.DurationVar(&r.stdinTimeout, "stdin-timeout", 0, "Fail if nothing is read from stdin within the duration (e.g. 5s). 0 means waiting forever.")
+	flagSet.BoolVarP(&r.edit, "edit", "e", false, "Compose the message in $VISUAL or $EDITOR.")
+	flagSet.StringVarP(&r.template, "template", "t", "", "Prefill the editor with the template of the given name in the config file. Used only when --edit is set.")
+	flagSet.BoolVar(&r.redact, "redact", false, "Mask detected secrets with [REDACTED:<rule>] and send the message instead of refusing to send it.")
+	flagSet.StringVarP(&r.output, "output", "o", "text", "Output format of detected secrets. 'text' or 'json'.")
+	flagSet.StringSliceVar(&r.allowSecrets, "allow-secrets", nil, "Comma-separated keys of the secret detection rules whose findings are sent anyway.")
 	return r
 }
 
@@ -45,3 +69,43 @@ func (r *Root) GetChannelName() (null.String, error) {
 func (r *Root) GetPrintBeforeSend() (bool, error) {
 	return r.printBeforeSend, nil
 }
+
+func (r *Root) GetMaxBytes() (int64, error) {
+	return r.maxBytes, nil
+}
+
+func (r *Root) GetHeadLines() (int, error) {
+	return r.headLines, nil
+}
+
+func (r *Root) GetTailLines() (int, error) {
+	return r.tailLines, nil
+}
+
+func (r *Root) GetWithStdin() (bool, error) {
+	return r.withStdin, nil
+}
+
+func (r *Root) GetStdinTimeout() (time.Duration, error) {
+	return r.stdinTimeout, nil
+}
+
+func (r *Root) GetEdit() (bool, error) {
+	return r.edit, nil
+}
+
+func (r *Root) GetTemplate() (null.String, error) {
+	return null.NewString(r.template, r.template != ""), nil
+}
+
+func (r *Root) GetRedact() (bool, error) {
+	return r.redact, nil
+}
+
+func (r *Root) GetOutput() (string, error) {
+	return r.output, nil
+}
+
+func (r *Root) GetAllowSecrets() ([]string, error) {
+	return r.allowSecrets, nil
+}
@@ -0,0 +1,29 @@
+package flag
+
+import (
+	"github.com/ikura-hamu/q-cli/internal/cmd"
+	"github.com/ikura-hamu/q-cli/internal/config"
+)
+
+type Scan struct {
+	staged bool
+	output string
+}
+
+var _ config.Scan = (*Scan)(nil)
+
+func NewScan(c *cmd.ScanBare) *Scan {
+	scan := &Scan{}
+	c.Flags().BoolVar(&scan.staged, "staged", false, "Scan the lines added by the changes staged in git, for a pre-commit hook.")
+	c.Flags().StringVarP(&scan.output, "output", "o", "text", "Output format of the findings. 'text' or 'json'.")
+
+	return scan
+}
+
+func (s *Scan) GetStaged() (bool, error) {
+	return s.staged, nil
+}
+
+func (s *Scan) GetOutput() (string, error) {
+	return s.output, nil
+}
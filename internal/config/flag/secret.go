@@ -0,0 +1,28 @@
+package flag
+
+import (
+	"github.com/ikura-hamu/q-cli/internal/config"
+	"github.com/spf13/pflag"
+)
+
+type Secret struct {
+	ignore []string
+	enable []string
+}
+
+var _ config.SecretCheckers = (*Secret)(nil)
+
+func NewSecret(flagSet *pflag.FlagSet) *Secret {
+	s := &Secret{}
+	flagSet.StringSliceVar(&s.ignore, "secret-ignore", nil, "Comma-separated keys of the secret detection rules to disable. See 'q secret rules'.")
+	flagSet.StringSliceVar(&s.enable, "secret-enable", nil, "Comma-separated keys of the opt-in secret detection rules to enable. See 'q secret rules'.")
+	return s
+}
+
+func (s *Secret) GetIgnore() ([]string, error) {
+	return s.ignore, nil
+}
+
+func (s *Secret) GetEnable() ([]string, error) {
+	return s.enable, nil
+}
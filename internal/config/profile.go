@@ -0,0 +1,9 @@
+package config
+
+import "github.com/guregu/null/v6"
+
+// Profile selects a profile of the config file.
+type Profile interface {
+	// GetProfile returns the name of the selected profile, if any.
+	GetProfile() (null.String, error)
+}
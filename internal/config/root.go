@@ -1,6 +1,10 @@
 package config
 
-import "github.com/guregu/null/v6"
+import (
+	"time"
+
+	"github.com/guregu/null/v6"
+)
 
 type Root interface {
 	GetVersion() (bool, error)
@@ -8,4 +12,14 @@ type Root interface {
 	GetCodeBlockLang() (null.String, error)
 	GetChannelName() (null.String, error)
 	GetPrintBeforeSend() (bool, error)
+	GetMaxBytes() (int64, error)
+	GetHeadLines() (int, error)
+	GetTailLines() (int, error)
+	GetWithStdin() (bool, error)
+	GetStdinTimeout() (time.Duration, error)
+	GetEdit() (bool, error)
+	GetTemplate() (null.String, error)
+	GetRedact() (bool, error)
+	GetOutput() (string, error)
+	GetAllowSecrets() ([]string, error)
 }
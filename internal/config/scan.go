@@ -0,0 +1,6 @@
+package config
+
+type Scan interface {
+	GetStaged() (bool, error)
+	GetOutput() (string, error)
+}
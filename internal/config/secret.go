@@ -0,0 +1,55 @@
+package config
+
+type SecretRule struct {
+	ID          string
+	Description string
+	Regex       string
+	SecretGroup int
+	Keywords    []string
+	Allowlist   []string
+	Severity    string
+}
+
+// SecretEntropy is the thresholds of the entropy checker. Zero values mean the defaults.
+type SecretEntropy struct {
+	MinLength       int
+	HexThreshold    float64
+	Base64Threshold float64
+}
+
+// SecretCheckers selects the checkers of the secret detection by their keys.
+type SecretCheckers interface {
+	// GetIgnore returns the keys of the checkers to disable.
+	GetIgnore() ([]string, error)
+	// GetEnable returns the keys of the opt-in checkers to enable.
+	GetEnable() ([]string, error)
+}
+
+type Secret interface {
+	SecretCheckers
+	GetRules() ([]SecretRule, error)
+	GetGitleaksConfigs() ([]string, error)
+	GetGitSecrets() (bool, error)
+	GetEntropy() (SecretEntropy, error)
+	// GetAllowlist returns regexes of matches which are not secrets, for every rule.
+	GetAllowlist() ([]string, error)
+	// GetKnownSecrets returns the values of the config keys which are secrets themselves, such as webhook_secret.
+	GetKnownSecrets() ([]string, error)
+	// GetKnownSecretFiles returns the paths of files listing secret values.
+	GetKnownSecretFiles() ([]string, error)
+	GetPII() (SecretPII, error)
+	// GetSeverities maps rule keys, or "*" for every rule, to their severity.
+	GetSeverities() (map[string]string, error)
+	// GetChannelSeverities maps channel names to the severities in the channel, which override GetSeverities.
+	GetChannelSeverities() (map[string]map[string]string, error)
+	// GetAuditFile returns the path of the audit log. Empty means the default path.
+	GetAuditFile() (string, error)
+}
+
+// SecretPII enables the PII checkers.
+type SecretPII struct {
+	// Rules maps the keys of the PII checkers to enable to their severity, "warn" or "block".
+	// The keys may be in any case.
+	Rules              map[string]string
+	InstitutionIDRegex string
+}
@@ -0,0 +1,5 @@
+package config
+
+type Template interface {
+	GetTemplate(name string) (string, error)
+}
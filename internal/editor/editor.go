@@ -0,0 +1,17 @@
+package editor
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrEmptyMessage = errors.New("empty message")
+
+//go:generate go run github.com/matryer/moq -pkg mock -out mock/${GOFILE}.go . Editor
+
+type Editor interface {
+	// Edit opens an editor prefilled with initial and the help lines as comments,
+	// and returns the edited message without the comments.
+	// If the result is empty, it should return ErrEmptyMessage.
+	Edit(ctx context.Context, initial string, help []string) (string, error)
+}
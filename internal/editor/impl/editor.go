@@ -0,0 +1,127 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/ikura-hamu/q-cli/internal/editor"
+	"github.com/ikura-hamu/q-cli/internal/pkg/tty"
+	"github.com/ras0q/goalie"
+	"golang.org/x/term"
+)
+
+// CommentPrefix is the prefix of the lines removed from the edited message.
+// "#" alone is not used because it is a heading in traQ Markdown.
+const CommentPrefix = "#:"
+
+type Editor struct{}
+
+var _ editor.Editor = (*Editor)(nil)
+
+func NewEditor() *Editor {
+	return &Editor{}
+}
+
+func (e *Editor) Edit(ctx context.Context, initial string, help []string) (mes string, err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	f, err := os.CreateTemp("", "q-message-*.md")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	defer g.Guard(func() error {
+		return os.Remove(f.Name())
+	})
+
+	content := initial + "\n"
+	for _, h := range help {
+		content += fmt.Sprintf("%s %s\n", CommentPrefix, h)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("write temp file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("close temp file: %w", err)
+	}
+
+	if err := run(ctx, f.Name()); err != nil {
+		return "", err
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("read temp file: %w", err)
+	}
+
+	mes = stripComments(string(b))
+	if mes == "" {
+		return "", editor.ErrEmptyMessage
+	}
+
+	return mes, nil
+}
+
+func run(ctx context.Context, path string) (err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	name := command()
+	cmd := editorCommand(ctx, name, path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	// The message may have come from a pipe. The editor needs the terminal anyway.
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		t, err := tty.Open()
+		if err != nil {
+			return fmt.Errorf("cannot open an editor without a terminal: %w", err)
+		}
+		defer g.Guard(t.Close)
+		cmd.Stdin, cmd.Stdout = t.In(), t.Out()
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run editor '%s': %w", name, err)
+	}
+	return nil
+}
+
+// editorCommand returns the command opening path with the editor.
+// Like git, the editor is run by the shell, so that it can have arguments and a quoted path with spaces.
+func editorCommand(ctx context.Context, editor string, path string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		args := strings.Fields(editor)
+		return exec.CommandContext(ctx, args[0], append(args[1:], path)...)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", editor+` "$@"`, editor, path)
+}
+
+// command returns the editor command in the same order as git: $VISUAL, $EDITOR, then a default.
+func command() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+			return v
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+func stripComments(s string) string {
+	lines := strings.Split(s, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if strings.HasPrefix(strings.TrimRight(l, "\r"), CommentPrefix) {
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
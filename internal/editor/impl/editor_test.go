@@ -0,0 +1,60 @@
+package impl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_stripComments(t *testing.T) {
+	testCases := map[string]struct {
+		content  string
+		expected string
+	}{
+		"コメントなし": {"hello\nworld\n", "hello\nworld"},
+		"コメントあり": {"hello\n#: help\n#: Channel: general\n", "hello"},
+		"見出しは残す": {"# title\nbody\n#: help\n", "# title\nbody"},
+		"CRLF":   {"hello\r\n#: help\r\n", "hello"},
+		"コメントのみ": {"\n#: help\n", ""},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, stripComments(tc.content))
+		})
+	}
+}
+
+func Test_editorCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the editor is not run by the shell on Windows")
+	}
+
+	dir := filepath.Join(t.TempDir(), "my editor")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	script := filepath.Join(dir, "edit.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\nprintf '%s' \"$1\" > \"$2\"\n"), 0o755))
+	path := filepath.Join(t.TempDir(), "message.md")
+
+	testCases := map[string]struct {
+		editor   string
+		expected string
+	}{
+		"引数つき":       {"'" + script + "' --wait", "--wait"},
+		"ダブルクォートの引数": {`"` + script + `" "a b"`, "a b"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, editorCommand(context.Background(), tc.editor, path).Run())
+			b, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(b))
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package message
+
+import "errors"
+
+var (
+	ErrInputTooLarge = errors.New("input is too large")
+	ErrBinaryInput   = errors.New("binary input is not supported")
+	ErrStdinTimeout  = errors.New("timed out waiting for stdin")
+	ErrCanceled      = errors.New("canceled")
+)
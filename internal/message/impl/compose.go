@@ -0,0 +1,161 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ikura-hamu/q-cli/internal/message"
+	"github.com/ikura-hamu/q-cli/internal/pkg/width"
+	"github.com/ras0q/goalie"
+	"golang.org/x/term"
+)
+
+const (
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlH     = 8
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// compose opens a multi-line prompt on the terminal in.
+// Ctrl-D sends the message and Esc or Ctrl-C cancels it.
+// Backspace at the start of a line joins it with the previous line.
+func compose(in *os.File, out io.Writer) (mes string, err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	fd := int(in.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("terminal make raw: %w", err)
+	}
+	defer g.Guard(func() error {
+		if err := term.Restore(fd, oldState); err != nil {
+			return fmt.Errorf("terminal restore: %w", err)
+		}
+		return nil
+	})
+
+	_, _ = fmt.Fprint(out, "Enter the message. Press Ctrl-D to send, Esc to cancel.\r\n")
+
+	lines := [][]rune{nil}
+	buf := make([]byte, 256)
+	var pending []byte
+	for {
+		n, err := in.Read(buf)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read from terminal: %w", err)
+		}
+
+		b := append(pending, buf[:n]...)
+		pending = nil
+
+		// A lone escape byte is the Esc key. Longer sequences are arrow keys and the like.
+		if len(b) == 1 && b[0] == keyEscape {
+			_, _ = fmt.Fprint(out, "\r\n")
+			return "", message.ErrCanceled
+		}
+
+		done := false
+	loop:
+		for len(b) > 0 {
+			cur := &lines[len(lines)-1]
+			switch c := b[0]; {
+			case c == keyCtrlC:
+				_, _ = fmt.Fprint(out, "\r\n")
+				return "", message.ErrCanceled
+			case c == keyCtrlD:
+				done = true
+				break loop
+			case c == keyEscape:
+				n := escapeSequence(b)
+				if n == 0 {
+					pending = b
+					break loop
+				}
+				b = b[n:]
+			case c == '\r' || c == '\n':
+				lines = append(lines, nil)
+				_, _ = fmt.Fprint(out, "\r\n")
+				b = b[1:]
+			case c == keyBackspace || c == keyCtrlH:
+				switch {
+				case len(*cur) > 0:
+					r := (*cur)[len(*cur)-1]
+					*cur = (*cur)[:len(*cur)-1]
+					w := width.Rune(r)
+					_, _ = fmt.Fprint(out, strings.Repeat("\b", w)+strings.Repeat(" ", w)+strings.Repeat("\b", w))
+				case len(lines) > 1:
+					// The current line is empty, so joining is removing it and moving to the end of the previous line.
+					lines = lines[:len(lines)-1]
+					_, _ = fmt.Fprint(out, "\x1b[A\r")
+					if w := width.String(string(lines[len(lines)-1])); w > 0 {
+						_, _ = fmt.Fprintf(out, "\x1b[%dC", w)
+					}
+				}
+				b = b[1:]
+			case c < 0x20 && c != '\t':
+				b = b[1:]
+			default:
+				if !utf8.FullRune(b) {
+					pending = b
+					break loop
+				}
+				r, size := utf8.DecodeRune(b)
+				*cur = append(*cur, r)
+				_, _ = out.Write(b[:size])
+				b = b[size:]
+			}
+		}
+		if done {
+			break
+		}
+	}
+	_, _ = fmt.Fprint(out, "\r\n")
+
+	strLines := make([]string, 0, len(lines))
+	for _, l := range lines {
+		strLines = append(strLines, string(l))
+	}
+
+	return strings.TrimSpace(strings.Join(strLines, "\n")), nil
+}
+
+// escapeSequence returns the length of the escape sequence at the head of b, such as "\x1b[A" (CSI) or "\x1bOP" (SS3).
+// It returns 1 for an ESC which does not start a sequence, so that the typed or pasted bytes after it are kept,
+// and 0 when b ends before the sequence does.
+func escapeSequence(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+	switch b[1] {
+	case '[':
+		for i := 2; i < len(b); i++ {
+			switch c := b[i]; {
+			case c >= 0x20 && c <= 0x3f:
+				// Parameter and intermediate bytes.
+			case c >= 0x40 && c <= 0x7e:
+				return i + 1
+			default:
+				return 1
+			}
+		}
+		return 0
+	case 'O':
+		if len(b) < 3 {
+			return 0
+		}
+		if b[2] >= 0x40 && b[2] <= 0x7e {
+			return 3
+		}
+	}
+	return 1
+}
@@ -0,0 +1,29 @@
+package impl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_escapeSequence(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		expected int
+	}{
+		"矢印キー":        {"\x1b[Aabc", 3},
+		"パラメータつき":     {"\x1b[1;5Cabc", 6},
+		"SS3":         {"\x1bOPabc", 3},
+		"シーケンスでないESC": {"\x1babc", 1},
+		"不正なCSI":      {"\x1b[\x01abc", 1},
+		"途中で終わるCSI":   {"\x1b[1;", 0},
+		"途中で終わるSS3":   {"\x1bO", 0},
+		"ESCだけ":       {"\x1b", 0},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, escapeSequence([]byte(tc.input)))
+		})
+	}
+}
@@ -1,53 +1,105 @@
 package impl
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/ikura-hamu/q-cli/internal/message"
+	"github.com/ikura-hamu/q-cli/internal/pkg/pipe"
+	"golang.org/x/term"
 )
 
-type Message struct{}
+type Message struct {
+	stdin io.Reader
+	// stdinIsPipe reports whether stdin is a pipe or a redirected file,
+	// e.g. `make test 2>&1 | q "CI failed:"`, and then the args are the caption of stdin.
+	stdinIsPipe func() bool
+}
 
 func NewMessage() *Message {
-	return &Message{}
+	return &Message{
+		stdin: os.Stdin,
+		stdinIsPipe: func() bool {
+			return pipe.IsPipe(os.Stdin)
+		},
+	}
 }
 
-func (m *Message) BuildMessage(args []string, option message.Option) (string, error) {
-	var message string
-	var err error
+func (m *Message) ReadMessage(args []string, option message.Option) (message.Content, error) {
+	if len(args) > 0 && (option.WithStdin || m.stdinIsPipe()) {
+		return m.readCaptionedMessage(strings.Join(args, " "), option)
+	}
 
 	if len(args) > 0 {
-		message = strings.Join(args, " ")
-	} else {
-		message, err = scan()
-		if err != nil {
-			return "", fmt.Errorf("failed to scan message: %w", err)
-		}
+		return message.Content{Body: strings.Join(args, " ")}, nil
+	}
+
+	body, err := m.readStdin(option)
+	if err != nil {
+		return message.Content{}, err
+	}
+
+	return message.Content{Body: body}, nil
+}
+
+// readCaptionedMessage uses caption as the first line and stdin as the body.
+// If stdin is empty, the message is only the caption.
+func (m *Message) readCaptionedMessage(caption string, option message.Option) (message.Content, error) {
+	body, err := m.readStdin(option)
+	if err != nil {
+		return message.Content{}, err
 	}
 
+	return message.Content{Caption: caption, Body: body}, nil
+}
+
+// FormatMessage joins the caption and the body. Only the body is put in a code block.
+func (m *Message) FormatMessage(content message.Content, option message.Option) string {
+	if content.Caption != "" && content.Body == "" {
+		return content.Caption
+	}
+
+	body := content.Body
 	if option.CodeBlock {
-		message = addCodeBlock(message, option.CodeBlockLang)
+		body = addCodeBlock(body, option.CodeBlockLang)
+	}
+
+	if content.Caption == "" {
+		return body
 	}
 
-	return message, nil
+	return content.Caption + "\n" + body
 }
 
-func scan() (string, error) {
-	sc := bufio.NewScanner(os.Stdin)
-	sb := &strings.Builder{}
-	for sc.Scan() {
-		text := sc.Text()
-		sb.WriteString(text + "\n")
+// readStdin reads the message from stdin, or opens a compose prompt when stdin is a terminal.
+func (m *Message) readStdin(option message.Option) (string, error) {
+	if f, ok := m.stdinTerminal(); ok {
+		if option.NoPrompt {
+			return "", nil
+		}
+		mes, err := compose(f, os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to compose message: %w", err)
+		}
+		return mes, nil
 	}
 
-	if err := sc.Err(); err != nil {
-		return "", fmt.Errorf("failed to read from stdin: %w", err)
+	mes, err := readInput(m.stdin, option)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan message: %w", err)
 	}
+	return mes, nil
+}
 
-	return strings.TrimSpace(sb.String()), nil
+// stdinTerminal returns stdin when it is an interactive terminal.
+func (m *Message) stdinTerminal() (*os.File, bool) {
+	f, ok := m.stdin.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return nil, false
+	}
+	return f, true
 }
 
 func addCodeBlock(baseMessage string, codeBlockLang string) string {
@@ -0,0 +1,48 @@
+package impl
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ikura-hamu/q-cli/internal/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadAndFormatMessage(t *testing.T) {
+	testCases := map[string]struct {
+		args     []string
+		stdin    io.Reader
+		option   message.Option
+		expected string
+	}{
+		"引数のみ": {
+			[]string{"hello", "world"}, nil, message.Option{}, "hello world",
+		},
+		"引数のみでコードブロック": {
+			[]string{"hello"}, nil, message.Option{CodeBlock: true}, "```\nhello\n```",
+		},
+		"標準入力のみ": {
+			nil, strings.NewReader("log\n"), message.Option{}, "log",
+		},
+		"引数と標準入力": {
+			[]string{"CI", "failed:"}, strings.NewReader("error\n"), message.Option{}, "CI failed:\nerror",
+		},
+		"引数と標準入力でコードブロック": {
+			[]string{"CI", "failed:"}, strings.NewReader("error\n"), message.Option{CodeBlock: true, CodeBlockLang: "sh"},
+			"CI failed:\n```sh\nerror\n```",
+		},
+		"標準入力が空": {
+			[]string{"hello"}, strings.NewReader(""), message.Option{CodeBlock: true}, "hello",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			m := &Message{stdin: tc.stdin, stdinIsPipe: func() bool { return tc.stdin != nil }}
+			content, err := m.ReadMessage(tc.args, tc.option)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, m.FormatMessage(content, tc.option))
+		})
+	}
+}
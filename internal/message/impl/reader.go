@@ -0,0 +1,206 @@
+package impl
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ikura-hamu/q-cli/internal/message"
+)
+
+const (
+	readBufferSize = 64 * 1024
+	// input is treated as binary when more than this ratio of its bytes is invalid UTF-8
+	invalidUTF8RatioLimit = 0.3
+)
+
+// readInput reads r line by line. Unlike bufio.Scanner there is no limit on the length of a line;
+// instead the whole input is limited by option.MaxBytes.
+// When option.HeadLines or option.TailLines is set, only the first / last lines are kept.
+func readInput(r io.Reader, option message.Option) (string, error) {
+	if option.StdinTimeout > 0 {
+		r = &timeoutReader{r: r, timeout: option.StdinTimeout}
+	}
+	br := bufio.NewReaderSize(r, readBufferSize)
+	truncate := option.HeadLines > 0 || option.TailLines > 0
+
+	var (
+		head      []string
+		tail      = newLineRing(option.TailLines)
+		omitted   int
+		readBytes int64
+		invalid   int
+	)
+
+	for {
+		line, err := readLine(br, option.MaxBytes)
+		if errors.Is(err, io.EOF) && line == nil {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+
+		if bytes.IndexByte(line, 0) >= 0 {
+			return "", binaryInputError()
+		}
+		invalid += countInvalidUTF8(line)
+		readBytes += int64(len(line))
+
+		text := strings.TrimRight(string(line), "\r\n")
+
+		switch {
+		case !truncate:
+			head = append(head, text)
+			if option.MaxBytes > 0 && readBytes > option.MaxBytes {
+				return "", tooLargeError(option.MaxBytes)
+			}
+		case len(head) < option.HeadLines:
+			head = append(head, text)
+		default:
+			if tail.push(text) {
+				omitted++
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	if readBytes > 0 && float64(invalid)/float64(readBytes) > invalidUTF8RatioLimit {
+		return "", binaryInputError()
+	}
+
+	lines := head
+	if omitted > 0 {
+		lines = append(lines, fmt.Sprintf("… %d lines omitted …", omitted))
+	}
+	lines = append(lines, tail.lines()...)
+
+	result := strings.ToValidUTF8(strings.Join(lines, "\n"), string(utf8.RuneError))
+	if option.MaxBytes > 0 && int64(len(result)) > option.MaxBytes {
+		return "", tooLargeError(option.MaxBytes)
+	}
+
+	return strings.TrimSpace(result), nil
+}
+
+// readLine reads a line including the trailing newline.
+// It stops reading as soon as the line gets longer than maxBytes.
+func readLine(br *bufio.Reader, maxBytes int64) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := br.ReadSlice('\n')
+		line = append(line, chunk...)
+		if maxBytes > 0 && int64(len(line)) > maxBytes {
+			return nil, tooLargeError(maxBytes)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			if len(line) == 0 {
+				return nil, io.EOF
+			}
+			return line, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from stdin: %w", err)
+		}
+		return line, nil
+	}
+}
+
+func countInvalidUTF8(b []byte) int {
+	invalid := 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size == 1 {
+			invalid++
+		}
+		b = b[size:]
+	}
+	return invalid
+}
+
+func tooLargeError(maxBytes int64) error {
+	return fmt.Errorf("stdin exceeds %d bytes. use --max-bytes to raise the limit, or --head/--tail to truncate it: %w", maxBytes, message.ErrInputTooLarge)
+}
+
+func binaryInputError() error {
+	return fmt.Errorf("stdin looks like a binary file. consider uploading it to traQ as an attachment instead: %w", message.ErrBinaryInput)
+}
+
+// timeoutReader fails when the first Read does not return within timeout,
+// so that scripts calling q without input do not hang.
+type timeoutReader struct {
+	r       io.Reader
+	timeout time.Duration
+	started bool
+}
+
+func (t *timeoutReader) Read(p []byte) (int, error) {
+	if t.started {
+		return t.r.Read(p)
+	}
+
+	type result struct {
+		n   int
+		err error
+	}
+	buf := make([]byte, len(p))
+	ch := make(chan result, 1)
+	go func() {
+		n, err := t.r.Read(buf)
+		ch <- result{n, err}
+	}()
+
+	select {
+	case res := <-ch:
+		t.started = true
+		copy(p, buf[:res.n])
+		return res.n, res.err
+	case <-time.After(t.timeout):
+		return 0, fmt.Errorf("no input within %s: %w", t.timeout, message.ErrStdinTimeout)
+	}
+}
+
+// lineRing keeps only the last n lines.
+type lineRing struct {
+	buf   []string
+	start int
+	size  int
+}
+
+func newLineRing(n int) *lineRing {
+	return &lineRing{buf: make([]string, n)}
+}
+
+// push adds a line and reports whether an old line (or the line itself) was dropped.
+func (r *lineRing) push(line string) bool {
+	if len(r.buf) == 0 {
+		return true
+	}
+	if r.size < len(r.buf) {
+		r.buf[(r.start+r.size)%len(r.buf)] = line
+		r.size++
+		return false
+	}
+	r.buf[r.start] = line
+	r.start = (r.start + 1) % len(r.buf)
+	return true
+}
+
+func (r *lineRing) lines() []string {
+	lines := make([]string, 0, r.size)
+	for i := range r.size {
+		lines = append(lines, r.buf[(r.start+i)%len(r.buf)])
+	}
+	return lines
+}
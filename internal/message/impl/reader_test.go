@@ -0,0 +1,85 @@
+package impl
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ikura-hamu/q-cli/internal/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readInput(t *testing.T) {
+	longLine := strings.Repeat("a", 100*1024)
+
+	testCases := map[string]struct {
+		input    string
+		option   message.Option
+		expected string
+		wantErr  error
+	}{
+		"ok": {
+			"hello\nworld\n", message.Option{}, "hello\nworld", nil,
+		},
+		"CRLF": {
+			"hello\r\nworld\r\n", message.Option{}, "hello\nworld", nil,
+		},
+		"64KiBを超える1行": {
+			longLine, message.Option{}, longLine, nil,
+		},
+		"max-bytesを超える": {
+			"hello\nworld\n", message.Option{MaxBytes: 8}, "", message.ErrInputTooLarge,
+		},
+		"max-bytesを超える1行": {
+			longLine, message.Option{MaxBytes: 1024}, "", message.ErrInputTooLarge,
+		},
+		"NULを含む": {
+			"hello\x00world", message.Option{}, "", message.ErrBinaryInput,
+		},
+		"不正なUTF-8が多い": {
+			"\xff\xfe\xfd\xfc\xfb\xfa", message.Option{}, "", message.ErrBinaryInput,
+		},
+		"不正なUTF-8が少しだけ": {
+			"こんにちは\xff", message.Option{}, "こんにちは�", nil,
+		},
+		"head": {
+			"1\n2\n3\n4\n5\n", message.Option{HeadLines: 2}, "1\n2\n… 3 lines omitted …", nil,
+		},
+		"tail": {
+			"1\n2\n3\n4\n5\n", message.Option{TailLines: 2}, "… 3 lines omitted …\n4\n5", nil,
+		},
+		"headとtail": {
+			"1\n2\n3\n4\n5\n", message.Option{HeadLines: 1, TailLines: 1}, "1\n… 3 lines omitted …\n5", nil,
+		},
+		"省略なし": {
+			"1\n2\n3\n", message.Option{HeadLines: 2, TailLines: 2}, "1\n2\n3", nil,
+		},
+		"省略するとmax-bytesに収まる": {
+			strings.Repeat("0123456789\n", 5), message.Option{MaxBytes: 40, TailLines: 1}, "… 4 lines omitted …\n0123456789", nil,
+		},
+		"空": {
+			"", message.Option{}, "", nil,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := readInput(strings.NewReader(tc.input), tc.option)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func Test_readInput_timeout(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close() //nolint:errcheck
+
+	_, err := readInput(r, message.Option{StdinTimeout: 10 * time.Millisecond})
+	assert.ErrorIs(t, err, message.ErrStdinTimeout)
+}
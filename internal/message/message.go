@@ -1,10 +1,34 @@
 package message
 
+import "time"
+
 type Option struct {
 	CodeBlock     bool
 	CodeBlockLang string
+	// MaxBytes is the maximum size of the message read from stdin. 0 means unlimited.
+	MaxBytes int64
+	// HeadLines and TailLines keep only the first / last N lines of stdin. 0 means disabled.
+	HeadLines int
+	TailLines int
+	// WithStdin uses args as a caption and stdin as the body.
+	// This is also enabled automatically when args are given and stdin is a pipe.
+	WithStdin bool
+	// StdinTimeout fails reading stdin when no data arrives within the duration. 0 means no timeout.
+	StdinTimeout time.Duration
+	// NoPrompt returns an empty message instead of opening the compose prompt when stdin is a terminal.
+	NoPrompt bool
+}
+
+// Content is a message before formatting.
+type Content struct {
+	// Caption is the first line of the message. It is never wrapped in a code block.
+	Caption string
+	Body    string
 }
 
 type Message interface {
-	BuildMessage(args []string, option Option) (string, error)
+	// ReadMessage reads the message from args and stdin.
+	ReadMessage(args []string, option Option) (Content, error)
+	// FormatMessage builds the message to send from content.
+	FormatMessage(content Content, option Option) string
 }
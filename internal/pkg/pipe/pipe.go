@@ -0,0 +1,14 @@
+package pipe
+
+import "os"
+
+// IsPipe reports whether f is a pipe or a redirected file, e.g. `git diff | q scan` or `q "log:" < app.log`.
+// Not being a terminal is not enough, because stdin is often /dev/null in CI and git hooks,
+// and reading it would silently give nothing.
+func IsPipe(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeNamedPipe != 0 || fi.Mode().IsRegular()
+}
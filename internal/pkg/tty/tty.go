@@ -0,0 +1,78 @@
+package tty
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ras0q/goalie"
+	"golang.org/x/term"
+)
+
+var ErrNoTTY = errors.New("no controlling terminal")
+
+// TTY is the controlling terminal of the process.
+// Unlike os.Stdin, it can be used for prompts even when stdin is a pipe.
+type TTY struct {
+	in  *os.File
+	out *os.File
+}
+
+// Open opens the controlling terminal. If there is none, it returns an error wrapping ErrNoTTY.
+func Open() (*TTY, error) {
+	in, out, err := open()
+	if err != nil {
+		return nil, fmt.Errorf("open controlling terminal: %w", errors.Join(ErrNoTTY, err))
+	}
+	return &TTY{in: in, out: out}, nil
+}
+
+func (t *TTY) Read(p []byte) (int, error) {
+	return t.in.Read(p)
+}
+
+func (t *TTY) Write(p []byte) (int, error) {
+	return t.out.Write(p)
+}
+
+// In returns the input side of the terminal.
+func (t *TTY) In() *os.File {
+	return t.in
+}
+
+// Out returns the output side of the terminal.
+func (t *TTY) Out() *os.File {
+	return t.out
+}
+
+func (t *TTY) Close() error {
+	if t.in == t.out {
+		return t.in.Close()
+	}
+	return errors.Join(t.in.Close(), t.out.Close())
+}
+
+// ReadLine prints prompt and reads a line in raw mode.
+func (t *TTY) ReadLine(prompt string) (line string, err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	fd := int(t.in.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("terminal make raw: %w", err)
+	}
+	defer g.Guard(func() error {
+		if err := term.Restore(fd, oldState); err != nil {
+			return fmt.Errorf("terminal restore: %w", err)
+		}
+		return nil
+	})
+
+	tm := term.NewTerminal(t, prompt)
+	line, err = tm.ReadLine()
+	if err != nil {
+		return "", fmt.Errorf("terminal read line: %w", err)
+	}
+	return line, nil
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package tty
+
+import "os"
+
+func open() (*os.File, *os.File, error) {
+	f, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f, nil
+}
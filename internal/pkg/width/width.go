@@ -0,0 +1,24 @@
+package width
+
+import (
+	"golang.org/x/text/width"
+)
+
+// Rune returns the number of terminal cells r occupies.
+func Rune(r rune) int {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// String returns the number of terminal cells s occupies.
+func String(s string) int {
+	w := 0
+	for _, r := range s {
+		w += Rune(r)
+	}
+	return w
+}
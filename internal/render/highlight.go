@@ -0,0 +1,137 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+)
+
+type language struct {
+	keywords       map[string]struct{}
+	lineComment    string
+	backtickString bool
+}
+
+func keywords(words ...string) map[string]struct{} {
+	m := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		m[w] = struct{}{}
+	}
+	return m
+}
+
+var (
+	goLang = language{
+		keywords: keywords("break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough",
+			"for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select",
+			"struct", "switch", "type", "var", "nil", "true", "false"),
+		lineComment:    "//",
+		backtickString: true,
+	}
+	pythonLang = language{
+		keywords: keywords("and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del", "elif",
+			"else", "except", "finally", "for", "from", "global", "if", "import", "in", "is", "lambda", "nonlocal",
+			"not", "or", "pass", "raise", "return", "try", "while", "with", "yield", "None", "True", "False"),
+		lineComment: "#",
+	}
+	jsLang = language{
+		keywords: keywords("async", "await", "break", "case", "catch", "class", "const", "continue", "default", "delete",
+			"do", "else", "export", "extends", "finally", "for", "from", "function", "if", "import", "in", "instanceof",
+			"interface", "let", "new", "return", "switch", "this", "throw", "try", "type", "typeof", "var", "while",
+			"yield", "null", "undefined", "true", "false"),
+		lineComment:    "//",
+		backtickString: true,
+	}
+	shellLang = language{
+		keywords: keywords("if", "then", "else", "elif", "fi", "for", "while", "until", "do", "done", "case", "esac",
+			"in", "function", "return", "export", "local", "echo", "cd", "exit"),
+		lineComment: "#",
+	}
+	rustLang = language{
+		keywords: keywords("as", "async", "await", "break", "const", "continue", "crate", "else", "enum", "extern",
+			"false", "fn", "for", "if", "impl", "in", "let", "loop", "match", "mod", "move", "mut", "pub", "ref",
+			"return", "self", "Self", "static", "struct", "super", "trait", "true", "type", "unsafe", "use", "where",
+			"while"),
+		lineComment: "//",
+	}
+	cLang = language{
+		keywords: keywords("auto", "break", "case", "char", "class", "const", "continue", "default", "do", "double",
+			"else", "enum", "extern", "final", "float", "for", "if", "import", "int", "long", "new", "private",
+			"protected", "public", "return", "short", "static", "struct", "switch", "this", "void", "while", "null",
+			"true", "false"),
+		lineComment: "//",
+	}
+)
+
+var languages = map[string]language{
+	"go":         goLang,
+	"golang":     goLang,
+	"py":         pythonLang,
+	"python":     pythonLang,
+	"js":         jsLang,
+	"javascript": jsLang,
+	"ts":         jsLang,
+	"typescript": jsLang,
+	"sh":         shellLang,
+	"bash":       shellLang,
+	"zsh":        shellLang,
+	"shell":      shellLang,
+	"rs":         rustLang,
+	"rust":       rustLang,
+	"c":          cLang,
+	"cpp":        cLang,
+	"java":       cLang,
+	"kotlin":     cLang,
+}
+
+// highlight colors keywords, strings, numbers and comments of a line of code.
+// Unknown languages are printed as is.
+func (r *Renderer) highlight(line string, lang string) string {
+	l, ok := languages[strings.ToLower(lang)]
+	if !ok || !r.color {
+		return line
+	}
+
+	var sb strings.Builder
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		rest := string(runes[i:])
+		switch {
+		case l.lineComment != "" && strings.HasPrefix(rest, l.lineComment):
+			sb.WriteString(r.style(rest, ansiGray))
+			return sb.String()
+		case c == '"' || c == '\'' || (c == '`' && l.backtickString):
+			j := i + 1
+			for ; j < len(runes) && runes[j] != c; j++ {
+				if runes[j] == '\\' {
+					j++
+				}
+			}
+			j = min(j+1, len(runes))
+			sb.WriteString(r.style(string(runes[i:j]), ansiGreen))
+			i = j
+		case unicode.IsDigit(c):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.' || runes[j] == 'x' || runes[j] == '_') {
+				j++
+			}
+			sb.WriteString(r.style(string(runes[i:j]), ansiMagenta))
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			word := string(runes[i:j])
+			if _, ok := l.keywords[word]; ok {
+				word = r.style(word, ansiBold+ansiBlue)
+			}
+			sb.WriteString(word)
+			i = j
+		default:
+			sb.WriteRune(c)
+			i++
+		}
+	}
+	return sb.String()
+}
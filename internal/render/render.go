@@ -0,0 +1,308 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ikura-hamu/q-cli/internal/pkg/width"
+)
+
+// MessageLengthLimit is the maximum number of characters of a traQ message.
+const MessageLengthLimit = 10000
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiReverse   = "\x1b[7m"
+	ansiStrike    = "\x1b[9m"
+	ansiRed       = "\x1b[31m"
+	ansiGreen     = "\x1b[32m"
+	ansiYellow    = "\x1b[33m"
+	ansiBlue      = "\x1b[34m"
+	ansiMagenta   = "\x1b[35m"
+	ansiCyan      = "\x1b[36m"
+	ansiGray      = "\x1b[90m"
+)
+
+type Renderer struct {
+	color bool
+}
+
+// NewRenderer returns a renderer of traQ Markdown for terminals.
+// When color is false, no ANSI escape sequence is written.
+func NewRenderer(color bool) *Renderer {
+	return &Renderer{color: color}
+}
+
+// Preview renders the message and appends its length and the limit.
+func (r *Renderer) Preview(message string) string {
+	length := utf8.RuneCountInString(message)
+	indicator := fmt.Sprintf("%d / %d characters", length, MessageLengthLimit)
+	if length > MessageLengthLimit {
+		indicator = r.style(indicator+" (too long)", ansiBold+ansiRed)
+	} else {
+		indicator = r.style(indicator, ansiGray)
+	}
+
+	return r.Render(message) + "\n" + indicator
+}
+
+var (
+	headingRegex     = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	listRegex        = regexp.MustCompile(`^(\s*)([-*+]|\d+\.)\s+(.*)$`)
+	codeFenceRegex   = regexp.MustCompile("^(`{3,}|~{3,})\\s*([^`\\s]*)")
+	tableRowRegex    = regexp.MustCompile(`^\s*\|.*\|\s*$`)
+	tableDelimRegex  = regexp.MustCompile(`^\s*\|?(\s*:?-+:?\s*\|)+\s*(:?-+:?\s*)?\|?\s*$`)
+	horizontalRegex  = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	ansiEscapeRegex  = regexp.MustCompile("\x1b\\[[0-9;]*m")
+	inlineTokenRegex = regexp.MustCompile("!\\{[^{}]*\\}|`[^`]+`|\\*\\*[^*]+\\*\\*|__[^_]+__|~~[^~]+~~|\\*[^*\\s][^*]*\\*|_[^_\\s][^_]*_|\\[[^\\]]+\\]\\([^)]+\\)")
+)
+
+// Render renders traQ Markdown with ANSI styles.
+// Control characters in the message are made visible, so that it cannot write escape sequences to the terminal.
+func (r *Renderer) Render(message string) string {
+	lines := strings.Split(escapeControls(message), "\n")
+	out := make([]string, 0, len(lines))
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := codeFenceRegex.FindStringSubmatch(line); m != nil {
+			fence, lang := m[1], m[2]
+			var code []string
+			j := i + 1
+			for ; j < len(lines); j++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[j]), fence) {
+					break
+				}
+				code = append(code, lines[j])
+			}
+			out = append(out, r.codeBlock(code, lang)...)
+			i = j
+			continue
+		}
+
+		if tableRowRegex.MatchString(line) && i+1 < len(lines) && tableDelimRegex.MatchString(lines[i+1]) {
+			rows := [][]string{splitTableRow(line)}
+			j := i + 2
+			for ; j < len(lines) && tableRowRegex.MatchString(lines[j]); j++ {
+				rows = append(rows, splitTableRow(lines[j]))
+			}
+			out = append(out, r.table(rows)...)
+			i = j - 1
+			continue
+		}
+
+		out = append(out, r.line(line))
+	}
+
+	return strings.Join(out, "\n")
+}
+
+func (r *Renderer) line(line string) string {
+	if m := headingRegex.FindStringSubmatch(line); m != nil {
+		return r.style(m[1]+" "+r.inline(m[2]), ansiBold+ansiUnderline)
+	}
+	if horizontalRegex.MatchString(line) {
+		return r.style(strings.Repeat("─", 24), ansiGray)
+	}
+	if strings.HasPrefix(line, ">") {
+		quote := strings.TrimPrefix(strings.TrimPrefix(line, ">"), " ")
+		return r.style("┃ ", ansiGray) + r.style(r.inline(quote), ansiItalic)
+	}
+	if m := listRegex.FindStringSubmatch(line); m != nil {
+		marker := "•"
+		if strings.HasSuffix(m[2], ".") {
+			marker = m[2]
+		}
+		return m[1] + r.style(marker, ansiCyan) + " " + r.inline(m[3])
+	}
+	return r.inline(line)
+}
+
+// escapeControls replaces the C0 and C1 control characters except tabs and newlines with a visible form,
+// e.g. ESC with "^[".
+func escapeControls(s string) string {
+	if !strings.ContainsFunc(s, isControl) {
+		return s
+	}
+
+	var sb strings.Builder
+	for _, c := range s {
+		switch {
+		case !isControl(c):
+			sb.WriteRune(c)
+		case c < 0x20:
+			sb.WriteString("^" + string(c+'@'))
+		case c == 0x7f:
+			sb.WriteString("^?")
+		default:
+			fmt.Fprintf(&sb, "<U+%04X>", c)
+		}
+	}
+	return sb.String()
+}
+
+func isControl(c rune) bool {
+	return unicode.IsControl(c) && c != '\n' && c != '\t'
+}
+
+// inline renders emphasis, inline code, links and embeds.
+// Underscores in a word, like snake_case, are not emphasis.
+func (r *Renderer) inline(s string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range inlineTokenRegex.FindAllStringIndex(s, -1) {
+		start, end := loc[0], loc[1]
+		if s[start] == '_' && (isWordRune(lastRune(s[:start])) || isWordRune(firstRune(s[end:]))) {
+			continue
+		}
+		sb.WriteString(s[last:start])
+		sb.WriteString(r.token(s[start:end]))
+		last = end
+	}
+	sb.WriteString(s[last:])
+	return sb.String()
+}
+
+func lastRune(s string) rune {
+	c, _ := utf8.DecodeLastRuneInString(s)
+	return c
+}
+
+func firstRune(s string) rune {
+	c, _ := utf8.DecodeRuneInString(s)
+	return c
+}
+
+func isWordRune(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// token renders an inline token matched by inlineTokenRegex.
+func (r *Renderer) token(tok string) string {
+	switch {
+	case strings.HasPrefix(tok, "!{"):
+		return r.embed(tok)
+	case strings.HasPrefix(tok, "`"):
+		return r.style(strings.Trim(tok, "`"), ansiReverse)
+	case strings.HasPrefix(tok, "**"), strings.HasPrefix(tok, "__"):
+		return r.style(tok[2:len(tok)-2], ansiBold)
+	case strings.HasPrefix(tok, "~~"):
+		return r.style(tok[2:len(tok)-2], ansiStrike)
+	case strings.HasPrefix(tok, "*"), strings.HasPrefix(tok, "_"):
+		return r.style(tok[1:len(tok)-1], ansiItalic)
+	case strings.HasPrefix(tok, "["):
+		text, url, _ := strings.Cut(tok[1:len(tok)-1], "](")
+		return r.style(text, ansiUnderline+ansiBlue) + " " + r.style("("+url+")", ansiGray)
+	}
+	return tok
+}
+
+// embed is a traQ embed such as !{"type":"user","raw":"@name","id":"..."}.
+type embed struct {
+	Type string `json:"type"`
+	Raw  string `json:"raw"`
+	ID   string `json:"id"`
+}
+
+// embed replaces a traQ embed with its raw text, e.g. "@name" or "#channel".
+func (r *Renderer) embed(tok string) string {
+	var e embed
+	if err := json.Unmarshal([]byte(tok[1:]), &e); err != nil || e.Raw == "" {
+		return tok
+	}
+
+	color := ansiCyan
+	if e.Type == "user" || e.Type == "group" {
+		color = ansiYellow
+	}
+	return r.style(e.Raw, ansiBold+color)
+}
+
+func (r *Renderer) codeBlock(code []string, lang string) []string {
+	out := make([]string, 0, len(code)+1)
+	if lang != "" {
+		out = append(out, r.style("╭ "+lang, ansiGray))
+	}
+	for _, l := range code {
+		out = append(out, r.style("│ ", ansiGray)+r.highlight(l, lang))
+	}
+	return out
+}
+
+func (r *Renderer) table(rows [][]string) []string {
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+
+	rendered := make([][]string, len(rows))
+	widths := make([]int, cols)
+	for i, row := range rows {
+		rendered[i] = make([]string, cols)
+		for j := range cols {
+			cell := ""
+			if j < len(row) {
+				cell = r.inline(row[j])
+			}
+			if i == 0 {
+				cell = r.style(cell, ansiBold)
+			}
+			rendered[i][j] = cell
+			widths[j] = max(widths[j], visibleWidth(cell))
+		}
+	}
+
+	border := func(left, mid, right string) string {
+		parts := make([]string, cols)
+		for j, w := range widths {
+			parts[j] = strings.Repeat("─", w+2)
+		}
+		return r.style(left+strings.Join(parts, mid)+right, ansiGray)
+	}
+
+	out := []string{border("┌", "┬", "┐")}
+	for i, row := range rendered {
+		var sb strings.Builder
+		sb.WriteString(r.style("│", ansiGray))
+		for j, cell := range row {
+			sb.WriteString(" " + cell + strings.Repeat(" ", widths[j]-visibleWidth(cell)) + " ")
+			sb.WriteString(r.style("│", ansiGray))
+		}
+		out = append(out, sb.String())
+		if i == 0 {
+			out = append(out, border("├", "┼", "┤"))
+		}
+	}
+	out = append(out, border("└", "┴", "┘"))
+
+	return out
+}
+
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	cells := strings.Split(line, "|")
+	for i, c := range cells {
+		cells[i] = strings.TrimSpace(c)
+	}
+	return cells
+}
+
+func visibleWidth(s string) int {
+	return width.String(ansiEscapeRegex.ReplaceAllString(s, ""))
+}
+
+func (r *Renderer) style(s string, codes string) string {
+	if !r.color || s == "" {
+		return s
+	}
+	return codes + s + ansiReset
+}
@@ -0,0 +1,58 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	testCases := map[string]struct {
+		message  string
+		expected string
+	}{
+		"プレーンテキスト": {"hello", "hello"},
+		"強調":       {"**bold** and *italic*", "bold and italic"},
+		"リスト":      {"- a\n  * b\n1. c", "• a\n  • b\n1. c"},
+		"引用":       {"> quote", "┃ quote"},
+		"単語の中の_":   {"foo_bar_baz and _italic_", "foo_bar_baz and italic"},
+		"制御文字":     {"a\x1b[2Jb\x07\tc\u009b", "a^[[2Jb^G\tc<U+009B>"},
+		"ユーザー埋め込み": {
+			`hi !{"type":"user","raw":"@ikura-hamu","id":"0e4b5b2c-6c21-4ac8-bc39-e58b4c27b8d1"}`,
+			"hi @ikura-hamu",
+		},
+		"壊れた埋め込み": {`!{"type":"user"}`, `!{"type":"user"}`},
+		"コードブロック": {"```go\nfunc main() {}\n```", "╭ go\n│ func main() {}"},
+		"テーブル": {
+			"| a | bb |\n|---|---|\n| ccc | d |",
+			"┌─────┬────┐\n│ a   │ bb │\n├─────┼────┤\n│ ccc │ d  │\n└─────┴────┘",
+		},
+		"全角を含むテーブル": {
+			"| 名前 |\n|---|\n| a |",
+			"┌──────┐\n│ 名前 │\n├──────┤\n│ a    │\n└──────┘",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, NewRenderer(false).Render(tc.message))
+		})
+	}
+}
+
+func TestRender_color(t *testing.T) {
+	actual := NewRenderer(true).Render("**bold**")
+	assert.Equal(t, ansiBold+"bold"+ansiReset, actual)
+
+	actual = NewRenderer(true).Render("```go\nreturn\n```")
+	assert.Contains(t, actual, ansiBold+ansiBlue+"return"+ansiReset)
+}
+
+func TestPreview(t *testing.T) {
+	actual := NewRenderer(false).Preview("hello")
+	assert.Equal(t, "hello\n5 / 10000 characters", actual)
+
+	actual = NewRenderer(false).Preview(strings.Repeat("a", MessageLengthLimit+1))
+	assert.True(t, strings.HasSuffix(actual, "10001 / 10000 characters (too long)"))
+}
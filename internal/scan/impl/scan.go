@@ -0,0 +1,141 @@
+package impl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ikura-hamu/q-cli/internal/scan"
+	"github.com/ras0q/goalie"
+)
+
+type Source struct {
+	stdin io.Reader
+}
+
+var _ scan.Source = (*Source)(nil)
+
+func NewSource() *Source {
+	return &Source{stdin: os.Stdin}
+}
+
+func (s *Source) Files(ctx context.Context, paths []string) ([]scan.Target, error) {
+	var targets []scan.Target
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if path == "-" {
+			br := bufio.NewReaderSize(s.stdin, binaryCheckSize)
+			head, err := br.Peek(binaryCheckSize)
+			if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+				return nil, fmt.Errorf("read stdin: %w", err)
+			}
+			if !isBinary(head) {
+				targets = append(targets, scan.Target{Name: "-", Open: func() (io.ReadCloser, error) {
+					return io.NopCloser(br), nil
+				}})
+			}
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{path}
+		if info.IsDir() {
+			files, err = listFiles(ctx, path)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, f := range files {
+			binary, err := isBinaryFile(f)
+			// Files deleted from the working tree are still listed by git ls-files.
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !binary {
+				targets = append(targets, scan.Target{Name: f, Open: func() (io.ReadCloser, error) {
+					return os.Open(f)
+				}})
+			}
+		}
+	}
+
+	return targets, nil
+}
+
+// listFiles returns the files in dir. In a git repository, the files ignored by git are skipped.
+func listFiles(ctx context.Context, dir string) ([]string, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "ls-files", "-z", "--cached", "--others", "--exclude-standard").Output()
+	if err == nil {
+		var files []string
+		for _, f := range strings.Split(string(out), "\x00") {
+			if f != "" {
+				files = append(files, filepath.Join(dir, filepath.FromSlash(f)))
+			}
+		}
+		return files, nil
+	}
+
+	// Not in a git repository, or git is not installed.
+	var files []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// binaryCheckSize is how many bytes at the head of a file are checked by isBinary, the same as git.
+const binaryCheckSize = 8000
+
+// isBinary reports whether b looks like a binary file, in the same way as git.
+func isBinary(b []byte) bool {
+	return bytes.IndexByte(b[:min(len(b), binaryCheckSize)], 0) >= 0
+}
+
+// isBinaryFile reports whether the file looks like a binary file, reading only its head.
+func isBinaryFile(path string) (binary bool, err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer g.Guard(f.Close)
+
+	head := make([]byte, binaryCheckSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, fmt.Errorf("read %s: %w", path, err)
+	}
+	return isBinary(head[:n]), nil
+}
@@ -0,0 +1,103 @@
+package impl
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ikura-hamu/q-cli/internal/scan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseDiff(t *testing.T) {
+	diff := `diff --git a/config.go b/config.go
+index 1111111..2222222 100644
+--- a/config.go
++++ b/config.go
+@@ -3,0 +4,2 @@ package config
++const token = "x"
++const other = "y"
+@@ -10 +12 @@ func f() {
+-	old()
++	new()
+diff --git a/removed.txt b/removed.txt
+index 3333333..4444444 100644
+--- a/removed.txt
++++ b/removed.txt
+@@ -1 +0,0 @@
+-gone
+diff --git "a/\343\201\202.txt" "b/\343\201\202.txt"
+new file mode 100644
+index 0000000..5555555
+--- /dev/null
++++ "b/\343\201\202.txt"
+@@ -0,0 +1 @@
++hello
+`
+
+	targets, err := parseDiff(diff)
+	require.NoError(t, err)
+	assert.Equal(t, []scan.Target{
+		{Name: "config.go", Text: "const token = \"x\"\nconst other = \"y\"\n\tnew()", Lines: []int{4, 5, 12}},
+		{Name: "あ.txt", Text: "hello", Lines: []int{1}},
+	}, targets)
+
+	assert.Equal(t, 12, targets[0].Line(3))
+}
+
+func Test_parseDiff_addedLineLikeHeader(t *testing.T) {
+	diff := `diff --git a/notes.md b/notes.md
+index 1111111..2222222 100644
+--- a/notes.md
++++ b/notes.md
+@@ -1,0 +2,3 @@
++++ b/other.md
++--- a/other.md
++token = "x"
+`
+
+	targets, err := parseDiff(diff)
+	require.NoError(t, err)
+	assert.Equal(t, []scan.Target{
+		{Name: "notes.md", Text: "++ b/other.md\n--- a/other.md\ntoken = \"x\"", Lines: []int{2, 3, 4}},
+	}, targets)
+}
+
+func TestSource_Files(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("b"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bin"), []byte("a\x00b"), 0o644))
+
+	s := &Source{stdin: strings.NewReader("stdin")}
+	targets, err := s.Files(context.Background(), []string{dir, "-"})
+	require.NoError(t, err)
+	texts := make(map[string]string)
+	for _, target := range targets {
+		require.NotNil(t, target.Open)
+		r, err := target.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		texts[target.Name] = string(b)
+	}
+	assert.Equal(t, map[string]string{
+		filepath.Join(dir, "a.txt"):        "a",
+		filepath.Join(dir, "sub", "b.txt"): "b",
+		"-":                                "stdin",
+	}, texts)
+
+	s = &Source{stdin: strings.NewReader("a\x00b")}
+	targets, err = s.Files(context.Background(), []string{"-"})
+	require.NoError(t, err)
+	assert.Empty(t, targets, "binary stdin")
+
+	_, err = s.Files(context.Background(), []string{filepath.Join(dir, "not_found")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package impl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ikura-hamu/q-cli/internal/scan"
+)
+
+func (s *Source) Staged(ctx context.Context) ([]scan.Target, error) {
+	out, err := gitOutput(ctx, "diff", "--cached", "--no-color", "--no-ext-diff", "--unified=0", "--diff-filter=d")
+	if err != nil {
+		return nil, err
+	}
+	return parseDiff(out)
+}
+
+func gitOutput(ctx context.Context, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// parseDiff collects the added lines of each file in a unified diff.
+func parseDiff(diff string) ([]scan.Target, error) {
+	var (
+		targets []scan.Target
+		cur     *scan.Target
+		text    []string
+		next    int
+		// header is whether the line is between "diff --git" and the first hunk,
+		// where "+++" is the file name rather than an added line starting with "++".
+		header bool
+	)
+	flush := func() {
+		if cur != nil && len(text) > 0 {
+			cur.Text = strings.Join(text, "\n")
+			targets = append(targets, *cur)
+		}
+		cur, text = nil, nil
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(diff))
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<30)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			header = true
+		case header && strings.HasPrefix(line, "+++ "):
+			name := strings.TrimPrefix(line, "+++ ")
+			if strings.HasPrefix(name, `"`) {
+				unquoted, err := strconv.Unquote(name)
+				if err != nil {
+					return nil, fmt.Errorf("invalid file name in diff: %s", name)
+				}
+				name = unquoted
+			}
+			cur = &scan.Target{Name: strings.TrimPrefix(name, "b/")}
+		case strings.HasPrefix(line, "@@"):
+			m := hunkHeaderRegex.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("invalid hunk header in diff: %s", line)
+			}
+			next, _ = strconv.Atoi(m[1])
+			header = false
+		case !header && strings.HasPrefix(line, "+") && cur != nil:
+			text = append(text, line[1:])
+			cur.Lines = append(cur.Lines, next)
+			next++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read diff: %w", err)
+	}
+	flush()
+
+	return targets, nil
+}
@@ -0,0 +1,37 @@
+package scan
+
+import (
+	"context"
+	"io"
+)
+
+//go:generate go run github.com/matryer/moq -pkg mock -out mock/${GOFILE}.go . Source
+
+// Target is a text to scan for secrets.
+type Target struct {
+	// Name is the file path, or "-" for stdin.
+	Name string
+	// Text is the text to scan, unless Open is set.
+	Text string
+	// Open opens the file to scan, so that a large file is read in chunks rather than as a whole.
+	Open func() (io.ReadCloser, error)
+	// Lines maps the 0-based line index in Text to the line number in the file.
+	// nil means the line numbers are the same.
+	Lines []int
+}
+
+// Line returns the line number in the file of the 1-based line in Text.
+func (t Target) Line(line int) int {
+	if t.Lines == nil || line < 1 || line > len(t.Lines) {
+		return line
+	}
+	return t.Lines[line-1]
+}
+
+type Source interface {
+	// Files reads the files. Directories are walked, skipping the files ignored by git.
+	// "-" reads stdin. Binary files are skipped. The targets are opened when they are scanned.
+	Files(ctx context.Context, paths []string) ([]Target, error)
+	// Staged returns the lines added by the changes staged in git.
+	Staged(ctx context.Context) ([]Target, error)
+}
@@ -0,0 +1,45 @@
+package secret
+
+import "time"
+
+// AuditAction is what happened to a message in which secrets were detected.
+type AuditAction string
+
+const (
+	// AuditBlocked means the message was not sent.
+	AuditBlocked AuditAction = "blocked"
+	// AuditRedacted means the message was sent with the secrets redacted.
+	AuditRedacted AuditAction = "redacted"
+	// AuditOverridden means the message was sent with the secrets, because the user chose to send it anyway
+	// or allowed the rules by --allow-secrets.
+	AuditOverridden AuditAction = "overridden"
+	// AuditWarned means the message was sent with the secrets, because every finding has the "warn" severity.
+	AuditWarned AuditAction = "warned"
+)
+
+// AuditEvent is a detection recorded in the audit log.
+type AuditEvent struct {
+	Time time.Time `json:"time"`
+	// Channel is the channel the message was sent to. Empty means the default channel.
+	Channel  string         `json:"channel,omitempty"`
+	Action   AuditAction    `json:"action"`
+	Findings []AuditFinding `json:"findings"`
+}
+
+// AuditFinding is a finding in the audit log. Only its fingerprint is kept, never the secret.
+// The log keeps the fingerprint as AuditFingerprint, not as it is printed for the ignore file.
+type AuditFinding struct {
+	Rule        CheckerKey `json:"rule"`
+	Fingerprint string     `json:"fingerprint"`
+}
+
+//go:generate go run github.com/matryer/moq -pkg mock -out mock/${GOFILE}.go . AuditLog
+
+// AuditLog is an append-only log of detection events.
+type AuditLog interface {
+	// Path returns the path of the log.
+	Path() string
+	Record(event AuditEvent) error
+	// Events returns every recorded event in order.
+	Events() ([]AuditEvent, error)
+}
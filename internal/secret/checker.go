@@ -1,8 +1,42 @@
 package secret
 
-type CheckerKey string
+import "github.com/ikura-hamu/q-cli/pkg/secretcheck"
 
+// CheckerKey, Checker and KeywordChecker are defined in secretcheck, so that checkers can be implemented outside of q-cli.
+type (
+	CheckerKey     = secretcheck.Key
+	Checker        = secretcheck.Checker
+	KeywordChecker = secretcheck.KeywordChecker
+)
+
+var (
+	GitHubCheckerKey            CheckerKey = "github"
+	PrivateKeyCheckerKey        CheckerKey = "privateKey"
+	AWSAccessKeyCheckerKey      CheckerKey = "awsAccessKey"
+	AWSSecretKeyCheckerKey      CheckerKey = "awsSecretKey"
+	GCPServiceAccountCheckerKey CheckerKey = "gcpServiceAccount"
+	SlackTokenCheckerKey        CheckerKey = "slackToken"
+	SlackWebhookCheckerKey      CheckerKey = "slackWebhook"
+	DiscordBotTokenCheckerKey   CheckerKey = "discordBotToken"
+	DiscordWebhookCheckerKey    CheckerKey = "discordWebhook"
+	StripeCheckerKey            CheckerKey = "stripe"
+	NpmCheckerKey               CheckerKey = "npm"
+	PyPICheckerKey              CheckerKey = "pypi"
+	GitLabCheckerKey            CheckerKey = "gitlab"
+	OpenAICheckerKey            CheckerKey = "openai"
+	AnthropicCheckerKey         CheckerKey = "anthropic"
+	TraQBotTokenCheckerKey      CheckerKey = "traQBotToken"
+	JWTCheckerKey               CheckerKey = "jwt"
+	DatabaseURLCheckerKey       CheckerKey = "databaseURL"
+	EntropyCheckerKey           CheckerKey = "entropy"
+	KnownSecretCheckerKey       CheckerKey = "knownSecret"
+)
+
+// PII checkers report personal data rather than credentials.
 var (
-	GitHubCheckerKey     CheckerKey = "github"
-	PrivateKeyCheckerKey CheckerKey = "privateKey"
+	EmailCheckerKey         CheckerKey = "email"
+	PhoneJPCheckerKey       CheckerKey = "phoneJP"
+	CreditCardCheckerKey    CheckerKey = "creditCard"
+	MyNumberCheckerKey      CheckerKey = "myNumber"
+	InstitutionIDCheckerKey CheckerKey = "institutionID"
 )
@@ -6,20 +6,20 @@ var _ error = (*errSecretDetectedT)(nil)
 
 type errSecretDetectedT struct {
 	error
-	mes string
+	findings []Finding
 }
 
 var errSecretDetected = errors.New("secret")
 
-func NewErrSecretDetected(mes string) error {
-	return &errSecretDetectedT{mes: mes, error: errSecretDetected}
+func NewErrSecretDetected(findings []Finding) error {
+	return &errSecretDetectedT{findings: findings, error: errSecretDetected}
 }
 
-func SecretDetected(err error) (string, bool) {
+func SecretDetected(err error) ([]Finding, bool) {
 	var errSD *errSecretDetectedT
 	if errors.As(err, &errSD) {
-		return errSD.mes, true
+		return errSD.findings, true
 	}
 
-	return "", false
+	return nil, false
 }
@@ -0,0 +1,43 @@
+package secret
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+)
+
+// AllowMarker is an inline marker. A finding on a line containing it is ignored.
+const AllowMarker = "q:allow-secret"
+
+// Fingerprint identifies a matched secret of a rule without revealing it.
+// The same secret found by the same rule always has the same fingerprint.
+func Fingerprint(key CheckerKey, match string) string {
+	sum := sha256.Sum256([]byte(string(key) + "\x00" + match))
+	return hex.EncodeToString(sum[:16])
+}
+
+// AuditFingerprint is the fingerprint kept in the audit log, keyed by a random key of the user.
+// The fingerprint alone is a plain hash, and that of a short value such as a phone number can be brute-forced.
+func AuditFingerprint(key []byte, fingerprint string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(fingerprint))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+var fingerprintRegex = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// ValidFingerprint reports whether s has the form of a fingerprint.
+func ValidFingerprint(s string) bool {
+	return fingerprintRegex.MatchString(s)
+}
+
+//go:generate go run github.com/matryer/moq -pkg mock -out mock/${GOFILE}.go . IgnoreFile
+
+// IgnoreFile stores the fingerprints of findings accepted as not secret.
+type IgnoreFile interface {
+	// Path returns the path of the file.
+	Path() string
+	// Add appends the fingerprints that are not in the file yet.
+	Add(fingerprints []string) error
+}
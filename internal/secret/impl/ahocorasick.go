@@ -0,0 +1,100 @@
+package impl
+
+import "context"
+
+// ahoCorasick finds every occurrence of a set of keywords in one pass over the text.
+// Keywords are matched case-insensitively for ASCII letters.
+type ahoCorasick struct {
+	// next is the transition table: next[state][b] is the state after reading the byte b.
+	next [][256]int32
+	// out is the keywords ending at each state, including the ones ending at its suffixes.
+	out [][]int
+	// lengths is the length of each keyword in bytes.
+	lengths []int
+}
+
+// keywordHit is an occurrence of a keyword at [start, end) in the text.
+type keywordHit struct {
+	keyword    int
+	start, end int
+}
+
+// cancelCheckInterval is how many bytes are scanned between checks of the context.
+const cancelCheckInterval = 64 << 10
+
+func newAhoCorasick(keywords []string) *ahoCorasick {
+	ac := &ahoCorasick{
+		next:    make([][256]int32, 1),
+		out:     make([][]int, 1),
+		lengths: make([]int, len(keywords)),
+	}
+
+	for i, kw := range keywords {
+		ac.lengths[i] = len(kw)
+		if kw == "" {
+			continue
+		}
+		s := int32(0)
+		for j := 0; j < len(kw); j++ {
+			b := toLower(kw[j])
+			// The root is never a child, so 0 means there is no transition yet.
+			if ac.next[s][b] == 0 {
+				ac.next = append(ac.next, [256]int32{})
+				ac.out = append(ac.out, nil)
+				ac.next[s][b] = int32(len(ac.next) - 1)
+			}
+			s = ac.next[s][b]
+		}
+		ac.out[s] = append(ac.out[s], i)
+	}
+
+	// Build the failure links in breadth-first order and turn them into transitions,
+	// so that matching never has to follow a link.
+	fail := make([]int32, len(ac.next))
+	queue := make([]int32, 0, len(ac.next))
+	for b := range 256 {
+		if child := ac.next[0][b]; child != 0 {
+			queue = append(queue, child)
+		}
+	}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		ac.out[u] = append(ac.out[u], ac.out[fail[u]]...)
+		for b := range 256 {
+			if v := ac.next[u][b]; v != 0 {
+				fail[v] = ac.next[fail[u]][b]
+				queue = append(queue, v)
+			} else {
+				ac.next[u][b] = ac.next[fail[u]][b]
+			}
+		}
+	}
+
+	return ac
+}
+
+// find returns every occurrence of the keywords in the text, ordered by their end.
+func (ac *ahoCorasick) find(ctx context.Context, text string) ([]keywordHit, error) {
+	var hits []keywordHit
+	s := int32(0)
+	for i := 0; i < len(text); i++ {
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		s = ac.next[s][toLower(text[i])]
+		for _, kw := range ac.out[s] {
+			hits = append(hits, keywordHit{kw, i + 1 - ac.lengths[kw], i + 1})
+		}
+	}
+	return hits, nil
+}
+
+func toLower(b byte) byte {
+	if 'A' <= b && b <= 'Z' {
+		return b + ('a' - 'A')
+	}
+	return b
+}
@@ -0,0 +1,58 @@
+package impl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ikura-hamu/q-cli/internal/secret"
+)
+
+// Allowlist returns an option ignoring matches of any of the regexes, whichever rule found them.
+func Allowlist(patterns []string) (func(sd *SecretDetector), error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret allowlist regex '%s': %w", p, err)
+		}
+		res = append(res, re)
+	}
+
+	return func(sd *SecretDetector) {
+		sd.allowlist = append(sd.allowlist, res...)
+	}, nil
+}
+
+// AllowFingerprints ignores the findings with the fingerprints.
+func AllowFingerprints(fingerprints []string) func(sd *SecretDetector) {
+	return func(sd *SecretDetector) {
+		for _, fp := range fingerprints {
+			sd.fingerprints[fp] = struct{}{}
+		}
+	}
+}
+
+// allowed reports whether the finding is allowed by an inline marker, the allowlist or a fingerprint.
+func (sd *SecretDetector) allowed(message string, f secret.Finding) bool {
+	lineStart := strings.LastIndexByte(message[:f.Start], '\n') + 1
+	lineEnd := len(message)
+	if i := strings.IndexByte(message[f.Start:], '\n'); i >= 0 {
+		lineEnd = f.Start + i
+	}
+	if strings.Contains(message[lineStart:lineEnd], secret.AllowMarker) {
+		return true
+	}
+
+	if _, ok := sd.fingerprints[f.Fingerprint]; ok {
+		return true
+	}
+
+	match := message[f.Start:f.End]
+	for _, re := range sd.allowlist {
+		if re.MatchString(match) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package impl
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/ikura-hamu/q-cli/internal/config"
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/ras0q/goalie"
+)
+
+// AuditFileName is the name of the audit log in the home directory.
+const AuditFileName = ".q-secret-audit.jsonl"
+
+// auditKeySuffix is appended to the path of the audit log for the key of its fingerprints.
+const auditKeySuffix = ".key"
+
+const auditKeySize = 32
+
+// AuditFile is an audit log in the JSON Lines format. Events are only appended, never rewritten.
+type AuditFile struct {
+	path string
+}
+
+var _ secret.AuditLog = (*AuditFile)(nil)
+
+func NewAuditFile(path string) *AuditFile {
+	return &AuditFile{path: path}
+}
+
+// NewAuditFileFactory returns a factory of the audit log at the path in the config,
+// or in the home directory by default.
+func NewAuditFileFactory(confFactory func() (config.Secret, error)) func() (secret.AuditLog, error) {
+	return func() (secret.AuditLog, error) {
+		conf, err := confFactory()
+		if err != nil {
+			return nil, fmt.Errorf("create secret config: %w", err)
+		}
+		path, err := conf.GetAuditFile()
+		if err != nil {
+			return nil, fmt.Errorf("get audit file: %w", err)
+		}
+		if path == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("get home directory: %w", err)
+			}
+			path = filepath.Join(home, AuditFileName)
+		}
+		return NewAuditFile(path), nil
+	}
+}
+
+func (f *AuditFile) Path() string {
+	return f.path
+}
+
+// Record appends the event as a line. The file is only readable by the user, because it shows what was sent where.
+// The fingerprints are keyed by the key next to the file. See secret.AuditFingerprint.
+func (f *AuditFile) Record(event secret.AuditEvent) (err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	key, err := f.key()
+	if err != nil {
+		return err
+	}
+	findings := make([]secret.AuditFinding, 0, len(event.Findings))
+	for _, af := range event.Findings {
+		findings = append(findings, secret.AuditFinding{Rule: af.Rule, Fingerprint: secret.AuditFingerprint(key, af.Fingerprint)})
+	}
+	event.Findings = findings
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode audit event: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.path, err)
+	}
+	defer g.Guard(file.Close)
+
+	// One write per event, so that concurrent writers do not interleave their lines.
+	if _, err := file.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("write %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// key returns the key of the fingerprints in the log. A random key is created on the first use,
+// only readable by the user like the log.
+func (f *AuditFile) key() (_ []byte, err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	path := f.path + auditKeySuffix
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		key := make([]byte, auditKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("generate audit key: %w", err)
+		}
+		// O_EXCL, so that concurrent writers do not overwrite the key of each other.
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			return f.key()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("create %s: %w", path, err)
+		}
+		defer g.Guard(file.Close)
+		if _, err := file.WriteString(hex.EncodeToString(key)); err != nil {
+			return nil, fmt.Errorf("write %s: %w", path, err)
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	key, err := hex.DecodeString(string(b))
+	if err != nil || len(key) != auditKeySize {
+		return nil, fmt.Errorf("invalid audit key in %s", path)
+	}
+	return key, nil
+}
+
+// Events returns the events in the file. A missing file has no events.
+func (f *AuditFile) Events() (events []secret.AuditEvent, err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	file, err := os.Open(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", f.path, err)
+	}
+	defer g.Guard(file.Close)
+
+	sc := bufio.NewScanner(file)
+	sc.Buffer(nil, 1<<20)
+	for i := 1; sc.Scan(); i++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e secret.AuditEvent
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid audit event: %w", f.path, i, err)
+		}
+		events = append(events, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", f.path, err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,75 @@
+package impl
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ikura-hamu/q-cli/internal/secret"
+)
+
+// benchmarkLog returns a log of about size bytes with a secret every 1000 lines.
+func benchmarkLog(size int) string {
+	sb := &strings.Builder{}
+	for i := 0; sb.Len() < size; i++ {
+		sb.WriteString("2024-04-01T12:00:00Z INFO request handled method=GET path=/api/v1/users status=200 duration=12ms\n")
+		if i%1000 == 0 {
+			sb.WriteString("2024-04-01T12:00:00Z DEBUG token=ghp_" + "123456789012345678901234567890123456\n")
+		}
+	}
+	return sb.String()
+}
+
+func BenchmarkSecretDetector_Detect(b *testing.B) {
+	for name, size := range map[string]int{"1KB": 1 << 10, "1MB": 1 << 20, "8MB": 8 << 20} {
+		b.Run(name, func(b *testing.B) {
+			message := benchmarkLog(size)
+			sd := NewSecretDetector()
+			b.SetBytes(int64(len(message)))
+			b.ResetTimer()
+			for range b.N {
+				_ = sd.Detect(context.Background(), message)
+			}
+		})
+	}
+}
+
+func BenchmarkSecretDetector_DetectReader(b *testing.B) {
+	message := benchmarkLog(8 << 20)
+	sd := NewSecretDetector()
+	b.SetBytes(int64(len(message)))
+	b.ResetTimer()
+	for range b.N {
+		_ = sd.DetectReader(context.Background(), strings.NewReader(message))
+	}
+}
+
+func BenchmarkSecretDetector_Detect_gitleaks(b *testing.B) {
+	rules, err := LoadGitleaksRules(filepath.Join("testdata", "gitleaks.toml"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	opt, err := CustomRules(rules)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	message := benchmarkLog(1 << 20)
+	sd := NewSecretDetector(opt, UseCheckers([]secret.CheckerKey{secret.EntropyCheckerKey}))
+	b.SetBytes(int64(len(message)))
+	b.ResetTimer()
+	for range b.N {
+		_ = sd.Detect(context.Background(), message)
+	}
+}
+
+func BenchmarkAhoCorasick(b *testing.B) {
+	message := benchmarkLog(1 << 20)
+	p := NewSecretDetector().prefilter
+	b.SetBytes(int64(len(message)))
+	b.ResetTimer()
+	for range b.N {
+		_, _ = p.ac.find(context.Background(), message)
+	}
+}
@@ -1,23 +1,143 @@
 package impl
 
-import "github.com/ikura-hamu/q-cli/internal/secret"
+import (
+	"maps"
+	"strings"
 
-type checker struct {
-	f       func(string) (bool, error)
-	message string
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/ikura-hamu/q-cli/pkg/secretcheck"
+)
+
+// checkerInfo is what the built-in checkers have in common. Each of them embeds it and implements Check.
+type checkerInfo struct {
+	key         secret.CheckerKey
+	description string
+	// keywords are the lower-case strings that appear in or just before every secret.
+	// If set, Check only runs on the windows around them. See prefilter.
+	keywords []string
+	// windowSize is how many bytes after a keyword a secret may end. 0 means defaultWindowAfter, and wholeText the whole text.
+	windowSize int
+}
+
+func (c checkerInfo) Key() secret.CheckerKey {
+	return c.key
 }
 
-var defaultCheckers = map[secret.CheckerKey]checker{
-	secret.GitHubCheckerKey:     {github, "GitHub secret key is detected."},
-	secret.PrivateKeyCheckerKey: {privateKey, "Private key is detected."},
+func (c checkerInfo) Description() string {
+	return c.description
 }
 
-var otherCheckers = map[secret.CheckerKey]checker{}
+func (c checkerInfo) Keywords() []string {
+	return c.keywords
+}
+
+func (c checkerInfo) window() int {
+	return c.windowSize
+}
+
+func init() {
+	for _, c := range defaultCheckers() {
+		secretcheck.DefaultRegistry.MustRegister(c)
+	}
+	for _, c := range otherCheckers() {
+		secretcheck.DefaultRegistry.MustRegister(c, secretcheck.OptIn())
+	}
+	for _, c := range piiCheckers() {
+		secretcheck.DefaultRegistry.MustRegister(c, secretcheck.OptIn(), secretcheck.WithDefaultSeverity(secret.SeverityWarn))
+	}
+}
 
+// defaultCheckers are the checkers used by default.
+func defaultCheckers() []secretcheck.Checker {
+	return []secretcheck.Checker{
+		regexChecker{checkerInfo{
+			key: secret.GitHubCheckerKey, description: "GitHub token",
+			keywords: []string{"ghp_", "gho_", "ghu_", "ghs_", "ghr_", "github_pat_"},
+		}, githubTokensRegex, 0},
+		privateKeyChecker{checkerInfo{
+			key: secret.PrivateKeyCheckerKey, description: "Private key",
+			keywords: []string{"-----begin"}, windowSize: 32 << 10,
+		}},
+		regexChecker{checkerInfo{
+			key: secret.AWSAccessKeyCheckerKey, description: "AWS access key ID",
+			keywords: []string{"akia", "asia", "abia", "acca", "a3t"},
+		}, awsAccessKeyRegex, 0},
+		regexChecker{checkerInfo{
+			key: secret.AWSSecretKeyCheckerKey, description: "AWS secret access key",
+			keywords: []string{"aws"},
+		}, awsSecretKeyRegex, 1},
+		regexChecker{checkerInfo{
+			key: secret.GCPServiceAccountCheckerKey, description: "GCP service account key",
+			keywords: []string{"private_key_id"},
+		}, gcpServiceAccountRegex, 1},
+		regexChecker{checkerInfo{
+			key: secret.SlackTokenCheckerKey, description: "Slack token",
+			keywords: []string{"xox", "xapp-"},
+		}, slackTokenRegex, 0},
+		regexChecker{checkerInfo{
+			key: secret.SlackWebhookCheckerKey, description: "Slack webhook URL",
+			keywords: []string{"hooks.slack.com"},
+		}, slackWebhookRegex, 0},
+		tokenChecker{checkerInfo{
+			key: secret.DiscordBotTokenCheckerKey, description: "Discord bot token",
+		}, discordBotTokenRegex, discordTokenBytes, 24 + 1 + 6 + 1 + 27},
+		regexChecker{checkerInfo{
+			key: secret.DiscordWebhookCheckerKey, description: "Discord webhook URL",
+			keywords: []string{"discord"},
+		}, discordWebhookRegex, 0},
+		regexChecker{checkerInfo{
+			key: secret.StripeCheckerKey, description: "Stripe API key",
+			keywords: []string{"sk_live_", "sk_test_", "rk_live_", "rk_test_"},
+		}, stripeRegex, 0},
+		regexChecker{checkerInfo{
+			key: secret.NpmCheckerKey, description: "npm access token",
+			keywords: []string{"npm_"},
+		}, npmRegex, 0},
+		regexChecker{checkerInfo{
+			key: secret.PyPICheckerKey, description: "PyPI API token",
+			keywords: []string{"pypi-"},
+		}, pypiRegex, 0},
+		regexChecker{checkerInfo{
+			key: secret.GitLabCheckerKey, description: "GitLab token",
+			keywords: []string{"glpat-", "gldt-", "glrt-", "glptt-", "glsoat-", "glcbt-", "glimt-", "glagent-", "gloas-", "gr1348941"},
+		}, gitlabRegex, 0},
+		regexChecker{checkerInfo{
+			key: secret.OpenAICheckerKey, description: "OpenAI API key",
+			keywords: []string{"sk-"},
+		}, openAIRegex, 0},
+		regexChecker{checkerInfo{
+			key: secret.AnthropicCheckerKey, description: "Anthropic API key",
+			keywords: []string{"sk-ant-"},
+		}, anthropicRegex, 0},
+		regexChecker{checkerInfo{
+			key: secret.TraQBotTokenCheckerKey, description: "traQ bot access token",
+			keywords: []string{"token", "bearer"},
+		}, traQBotTokenRegex, 1},
+		regexChecker{checkerInfo{
+			key: secret.JWTCheckerKey, description: "JSON Web Token",
+			keywords: []string{"eyj"}, windowSize: 16 << 10,
+		}, jwtRegex, 0},
+		regexChecker{checkerInfo{
+			key: secret.DatabaseURLCheckerKey, description: "Password in a database connection URL",
+			keywords: []string{"://"},
+		}, databaseURLRegex, 1},
+		newKnownSecretChecker(nil),
+	}
+}
+
+// otherCheckers are opt-in checkers enabled by UseCheckers.
+func otherCheckers() []secretcheck.Checker {
+	return []secretcheck.Checker{newEntropyChecker(DefaultEntropyConfig)}
+}
+
+// IgnoreCheckers disables the checkers. They can be enabled again by UseCheckers.
 func IgnoreCheckers(ignores []secret.CheckerKey) func(sd *SecretDetector) {
 	return func(sd *SecretDetector) {
 		for _, ignore := range ignores {
-			delete(sd.checkers, ignore)
+			if c, ok := sd.checkers[ignore]; ok {
+				sd.others[ignore] = c
+				delete(sd.checkers, ignore)
+			}
 		}
 	}
 }
@@ -25,9 +145,69 @@ func IgnoreCheckers(ignores []secret.CheckerKey) func(sd *SecretDetector) {
 func UseCheckers(use []secret.CheckerKey) func(sd *SecretDetector) {
 	return func(sd *SecretDetector) {
 		for _, u := range use {
-			if c, ok := otherCheckers[u]; ok {
+			if c, ok := sd.others[u]; ok {
 				sd.checkers[u] = c
+				delete(sd.others, u)
 			}
 		}
 	}
 }
+
+// WithEntropyConfig sets the thresholds of the entropy checker.
+// It does not enable the checker; use UseCheckers for that.
+func WithEntropyConfig(conf EntropyConfig) func(sd *SecretDetector) {
+	return func(sd *SecretDetector) {
+		sd.replace(newEntropyChecker(conf))
+	}
+}
+
+// WithSeverities overrides the severity of the checkers.
+func WithSeverities(severities map[secret.CheckerKey]secret.Severity) func(sd *SecretDetector) {
+	return func(sd *SecretDetector) {
+		maps.Copy(sd.severities, severities)
+	}
+}
+
+// anyChecker is the key for every checker in severity settings.
+const anyChecker secret.CheckerKey = "*"
+
+// WithChannelSeverities overrides the severity of the checkers in each channel.
+// The channel names are case-insensitive.
+func WithChannelSeverities(channels map[string]map[secret.CheckerKey]secret.Severity) func(sd *SecretDetector) {
+	return func(sd *SecretDetector) {
+		for name, severities := range channels {
+			name = strings.ToLower(name)
+			if sd.channelSeverities[name] == nil {
+				sd.channelSeverities[name] = make(map[secret.CheckerKey]secret.Severity)
+			}
+			maps.Copy(sd.channelSeverities[name], severities)
+		}
+	}
+}
+
+// severity returns the severity of the checker, from the most specific setting:
+// the checker in the channel, "*" in the channel, the checker, "*", and the default severity of the checker.
+func (sd *SecretDetector) severity(key secret.CheckerKey) secret.Severity {
+	for _, severities := range []map[secret.CheckerKey]secret.Severity{sd.channelSeverities[sd.channel], sd.severities} {
+		if sev, ok := severities[key]; ok {
+			return sev
+		}
+		if sev, ok := severities[anyChecker]; ok {
+			return sev
+		}
+	}
+	if sev, ok := sd.defaultSeverities[key]; ok {
+		return sev
+	}
+	return secret.SeverityBlock
+}
+
+// replace swaps the implementation of a checker, keeping whether it is active.
+func (sd *SecretDetector) replace(c secret.Checker) {
+	key := c.Key()
+	if _, ok := sd.checkers[key]; ok {
+		sd.checkers[key] = c
+	} else {
+		sd.others[key] = c
+	}
+}
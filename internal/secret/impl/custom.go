@@ -0,0 +1,132 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/ikura-hamu/q-cli/pkg/secretcheck"
+)
+
+// CustomRules returns an option adding checkers built from user-defined rules.
+// It fails when a regex is invalid or a rule ID conflicts with another rule.
+func CustomRules(rules []secret.Rule) (func(sd *SecretDetector), error) {
+	checkers := make(map[secret.CheckerKey]customChecker, len(rules))
+	severities := make(map[secret.CheckerKey]secret.Severity)
+	for _, r := range rules {
+		key := secret.CheckerKey(r.ID)
+		if r.ID == "" {
+			return nil, fmt.Errorf("secret rule with regex '%s': id is required", r.Regex)
+		}
+		if _, ok := secretcheck.DefaultRegistry.Lookup(key); ok {
+			return nil, fmt.Errorf("secret rule '%s': id conflicts with a built-in rule", r.ID)
+		}
+		if _, ok := checkers[key]; ok {
+			return nil, fmt.Errorf("secret rule '%s': duplicate id", r.ID)
+		}
+
+		c, err := newCustomChecker(r)
+		if err != nil {
+			return nil, fmt.Errorf("secret rule '%s': %w", r.ID, err)
+		}
+		checkers[key] = c
+
+		if r.Severity != "" {
+			sev, err := secret.ParseSeverity(r.Severity)
+			if err != nil {
+				return nil, fmt.Errorf("secret rule '%s': %w", r.ID, err)
+			}
+			severities[key] = sev
+		}
+	}
+
+	return func(sd *SecretDetector) {
+		for key, c := range checkers {
+			sd.checkers[key] = c
+		}
+		maps.Copy(sd.severities, severities)
+	}, nil
+}
+
+// customChecker is a checker of a user-defined rule.
+// The matches of an allowlist regex, and the matches on a line of a line allowlist regex, are not reported.
+type customChecker struct {
+	regexChecker
+	allowlist     []*regexp.Regexp
+	lineAllowlist []*regexp.Regexp
+}
+
+func newCustomChecker(r secret.Rule) (customChecker, error) {
+	re, err := regexp.Compile(r.Regex)
+	if err != nil {
+		return customChecker{}, fmt.Errorf("invalid regex: %w", err)
+	}
+	if r.SecretGroup < 0 || r.SecretGroup > re.NumSubexp() {
+		return customChecker{}, fmt.Errorf("secret group %d does not exist in regex '%s'", r.SecretGroup, r.Regex)
+	}
+
+	allowlist, err := compileAll(r.Allowlist)
+	if err != nil {
+		return customChecker{}, fmt.Errorf("invalid allowlist regex: %w", err)
+	}
+	lineAllowlist, err := compileAll(r.LineAllowlist)
+	if err != nil {
+		return customChecker{}, fmt.Errorf("invalid line allowlist regex: %w", err)
+	}
+
+	keywords := make([]string, 0, len(r.Keywords))
+	for _, k := range r.Keywords {
+		keywords = append(keywords, strings.ToLower(k))
+	}
+
+	description := r.Description
+	if description == "" {
+		description = fmt.Sprintf("Custom rule '%s'", r.ID)
+	}
+
+	// A rule runs on the whole message having one of its keywords, because the secret may be far from the keyword.
+	info := checkerInfo{key: secret.CheckerKey(r.ID), description: description, keywords: keywords, windowSize: wholeText}
+	return customChecker{regexChecker{info, re, r.SecretGroup}, allowlist, lineAllowlist}, nil
+}
+
+func compileAll(exprs []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(exprs))
+	for _, e := range exprs {
+		re, err := regexp.Compile(e)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func (c customChecker) Check(_ context.Context, text string) ([]secretcheck.Finding, error) {
+	matches := c.find(text)
+	kept := matches[:0]
+loop:
+	for _, m := range matches {
+		for _, a := range c.allowlist {
+			if a.MatchString(text[m.Start:m.End]) {
+				continue loop
+			}
+		}
+		if len(c.lineAllowlist) > 0 {
+			start, end := strings.LastIndexByte(text[:m.Start], '\n')+1, len(text)
+			if i := strings.IndexByte(text[m.End:], '\n'); i >= 0 {
+				end = m.End + i
+			}
+			line := text[start:end]
+			for _, a := range c.lineAllowlist {
+				if a.MatchString(line) {
+					continue loop
+				}
+			}
+		}
+		kept = append(kept, m)
+	}
+	return kept, nil
+}
@@ -0,0 +1,170 @@
+package impl
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ikura-hamu/q-cli/internal/secret"
+)
+
+const (
+	// maxDecodeDepth is how many times a blob is decoded, e.g. 2 for base64 in base64.
+	maxDecodeDepth = 3
+	// minEncodedLength is the minimum length of a blob to decode.
+	minEncodedLength = 16
+)
+
+var (
+	percentRegex  = regexp.MustCompile(`%[0-9A-Fa-f]{2}`)
+	hexTokenRegex = regexp.MustCompile(`^(?:[0-9A-Fa-f]{2})+$`)
+)
+
+// decoder decodes a blob. It returns false if the blob is not in its encoding.
+type decoder struct {
+	name   string
+	decode func(string) (string, bool)
+}
+
+// decoders are tried in order, and the first one that decodes a blob into text wins.
+var decoders = []decoder{
+	{"hex", func(s string) (string, bool) {
+		s = strings.TrimPrefix(s, "0x")
+		if !hexTokenRegex.MatchString(s) {
+			return "", false
+		}
+		b, err := hex.DecodeString(s)
+		return string(b), err == nil
+	}},
+	{"base64", func(s string) (string, bool) {
+		if strings.ContainsAny(s, "-_") {
+			return "", false
+		}
+		b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+		return string(b), err == nil
+	}},
+	{"base64url", func(s string) (string, bool) {
+		if strings.ContainsAny(s, "+/") {
+			return "", false
+		}
+		b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+		return string(b), err == nil
+	}},
+}
+
+// findEncoded decodes the base64, base64url, hex and percent-encoded blobs in the message and checks the decoded text.
+// The findings cover the whole blob in the message, and their Encoding is the path of encodings to the secret.
+func (sd *SecretDetector) findEncoded(ctx context.Context, message string, depth int) ([]secret.Finding, error) {
+	type blob struct {
+		start, end int
+		encoding   string
+		decoded    string
+	}
+	var blobs []blob
+
+	for _, loc := range tokens(message, encodedBytes, minEncodedLength) {
+		// Padding is not part of the alphabet, so it is added after the token.
+		end := loc[1]
+		for end < len(message) && end-loc[1] < 2 && message[end] == '=' {
+			end++
+		}
+		for _, d := range decoders {
+			if decoded, ok := d.decode(message[loc[0]:end]); ok && isText(decoded) {
+				blobs = append(blobs, blob{loc[0], end, d.name, decoded})
+				break
+			}
+		}
+	}
+	for _, loc := range tokens(message, urlBytes, minEncodedLength) {
+		token := message[loc[0]:loc[1]]
+		if !percentRegex.MatchString(token) {
+			continue
+		}
+		if decoded, err := url.QueryUnescape(token); err == nil && isText(decoded) {
+			blobs = append(blobs, blob{loc[0], loc[1], "percent", decoded})
+		}
+	}
+
+	var findings []secret.Finding
+	for _, b := range blobs {
+		decodedFindings, err := sd.findIn(ctx, b.decoded, depth-1)
+		if err != nil {
+			return nil, err
+		}
+		line, column := position(message, b.start)
+		for _, f := range decodedFindings {
+			f.Encoding = append([]string{b.encoding}, f.Encoding...)
+			f.Line, f.Column = line, column
+			f.Start, f.End = b.start, b.end
+			if !sd.allowed(message, f) {
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings, nil
+}
+
+// byteSet is a set of bytes, looked up faster than a function.
+type byteSet [256]bool
+
+func newByteSet(in func(b byte) bool) *byteSet {
+	var set byteSet
+	for b := range 256 {
+		set[b] = in(byte(b))
+	}
+	return &set
+}
+
+var (
+	encodedBytes = newByteSet(isEncodedByte)
+	urlBytes     = newByteSet(isURLByte)
+)
+
+// tokens returns the [start, end) offsets of the runs of bytes in the set that are at least minLength bytes long.
+// It scans the message once, which is much faster than a regex with a leading repetition.
+func tokens(message string, set *byteSet, minLength int) [][2]int {
+	var locs [][2]int
+	start := 0
+	for i := 0; i < len(message); i++ {
+		if set[message[i]] {
+			continue
+		}
+		if i-start >= minLength {
+			locs = append(locs, [2]int{start, i})
+		}
+		start = i + 1
+	}
+	if len(message)-start >= minLength {
+		locs = append(locs, [2]int{start, len(message)})
+	}
+	return locs
+}
+
+// isEncodedByte reports whether b is in the alphabet of base64, base64url or hex.
+func isEncodedByte(b byte) bool {
+	return 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z' || '0' <= b && b <= '9' || b == '+' || b == '/' || b == '_' || b == '-'
+}
+
+// isURLByte reports whether b can be in a percent-encoded token, which ends at a space, a quote or an angle bracket.
+func isURLByte(b byte) bool {
+	return !isSpaceByte(b) && b != '"' && b != '\'' && b != '<' && b != '>'
+}
+
+// isText reports whether the decoded bytes look like text rather than binary.
+func isText(s string) bool {
+	if s == "" || !utf8.ValidString(s) {
+		return false
+	}
+	printable := 0
+	for _, r := range s {
+		if unicode.IsPrint(r) || unicode.IsSpace(r) {
+			printable++
+		}
+	}
+	return printable*10 >= utf8.RuneCountInString(s)*9
+}
@@ -0,0 +1,247 @@
+package impl
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/ikura-hamu/q-cli/pkg/secretcheck"
+)
+
+const (
+	// windowBefore and defaultWindowAfter are how far a candidate window reaches around a keyword, in bytes.
+	// A checker with a longer secret sets its own window.
+	windowBefore       = 128
+	defaultWindowAfter = 1024
+
+	// chunkSize and chunkOverlap are the sizes used by DetectReader.
+	// Every secret is checked with at least chunkOverlap bytes of context on both sides,
+	// which must be more than the window of any checker.
+	chunkSize    = 1 << 20
+	chunkOverlap = 64 << 10
+)
+
+// wholeText is the window of a checker that checks the whole text when it has one of its keywords anywhere,
+// like the keywords of gitleaks rules. For DetectReader, the whole text is a chunk.
+const wholeText = -1
+
+// prefilter finds the candidate windows of the checkers with keywords in one pass over the text.
+// Checkers without keywords check the whole text.
+type prefilter struct {
+	ac *ahoCorasick
+	// checkers is the checkers having each keyword of ac.
+	checkers [][]secret.CheckerKey
+}
+
+// windowed is a checker with a custom window size. See checker.windowSize.
+type windowed interface {
+	window() int
+}
+
+func newPrefilter(checkers map[secret.CheckerKey]secret.Checker) *prefilter {
+	index := make(map[string]int)
+	var keywords []string
+	var keys [][]secret.CheckerKey
+	for _, key := range slices.Sorted(maps.Keys(checkers)) {
+		for _, kw := range checkerKeywords(checkers[key]) {
+			kw = strings.ToLower(kw)
+			i, ok := index[kw]
+			if !ok {
+				i = len(keywords)
+				index[kw] = i
+				keywords = append(keywords, kw)
+				keys = append(keys, nil)
+			}
+			if !slices.Contains(keys[i], key) {
+				keys[i] = append(keys[i], key)
+			}
+		}
+	}
+	return &prefilter{newAhoCorasick(keywords), keys}
+}
+
+// windows returns the merged [start, end) candidate windows of each checker with keywords.
+// A checker with keywords but no window has nothing to check.
+func (p *prefilter) windows(ctx context.Context, text string, checkers map[secret.CheckerKey]secret.Checker) (map[secret.CheckerKey][][2]int, error) {
+	hits, err := p.ac.find(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make(map[secret.CheckerKey][][2]int)
+	for _, h := range hits {
+		for _, key := range p.checkers[h.keyword] {
+			after := defaultWindowAfter
+			if w, ok := checkers[key].(windowed); ok {
+				after = cmp.Or(w.window(), after)
+			}
+			if after == wholeText {
+				windows[key] = [][2]int{{0, len(text)}}
+				continue
+			}
+			windows[key] = append(windows[key], window(text, h.start, h.end, after))
+		}
+	}
+	for key, ws := range windows {
+		windows[key] = mergeWindows(ws)
+	}
+	return windows, nil
+}
+
+// window returns the range around a keyword at [start, end).
+// Its edges are moved to whitespace when possible, so that a token is not cut in the middle.
+func window(text string, start, end, after int) [2]int {
+	lo := max(start-windowBefore, 0)
+	for i := lo; i < start; i++ {
+		if i == 0 || isSpaceByte(text[i-1]) {
+			lo = i
+			break
+		}
+	}
+	hi := min(end+after, len(text))
+	for i := hi; i > end; i-- {
+		if i == len(text) || isSpaceByte(text[i]) {
+			hi = i
+			break
+		}
+	}
+	return [2]int{lo, hi}
+}
+
+func mergeWindows(ws [][2]int) [][2]int {
+	slices.SortFunc(ws, func(a, b [2]int) int {
+		return cmp.Compare(a[0], b[0])
+	})
+	merged := ws[:1]
+	for _, w := range ws[1:] {
+		last := &merged[len(merged)-1]
+		if w[0] <= last[1] {
+			last[1] = max(last[1], w[1])
+			continue
+		}
+		merged = append(merged, w)
+	}
+	return merged
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == '\v'
+}
+
+// checkerKeywords returns the keywords of the checker, or nil if it is not a secret.KeywordChecker.
+func checkerKeywords(c secret.Checker) []string {
+	if kc, ok := c.(secret.KeywordChecker); ok {
+		return kc.Keywords()
+	}
+	return nil
+}
+
+// locate runs a checker on its candidate windows, or on the whole text when it has no keywords,
+// and returns the matches with their offsets in the text.
+func (sd *SecretDetector) locate(ctx context.Context, text string, c secret.Checker, windows [][2]int) ([]secretcheck.Finding, error) {
+	if len(checkerKeywords(c)) == 0 {
+		return c.Check(ctx, text)
+	}
+
+	var matches []secretcheck.Finding
+	for _, w := range windows {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		found, err := c.Check(ctx, text[w[0]:w[1]])
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range found {
+			m.Start += w[0]
+			m.End += w[0]
+			matches = append(matches, m)
+		}
+	}
+	return matches, nil
+}
+
+// DetectReader reads the text from r in chunks and detects secrets in it, like Detect.
+// Chunks overlap so that a secret on a boundary is found once, with its line and column in the whole text.
+func (sd *SecretDetector) DetectReader(ctx context.Context, r io.Reader) error {
+	findings, err := sd.findReader(ctx, r, chunkSize, chunkOverlap)
+	if err != nil {
+		return err
+	}
+	if len(findings) > 0 {
+		return secret.NewErrSecretDetected(findings)
+	}
+
+	return nil
+}
+
+// findReader finds the secrets in the text read from r, keeping at most size+2*overlap bytes in memory.
+// Each buffer is checked as a whole, and a finding is reported by the buffer in which it starts
+// at least overlap bytes away from the edges, except the edges of the text.
+func (sd *SecretDetector) findReader(ctx context.Context, r io.Reader, size, overlap int) ([]secret.Finding, error) {
+	var findings []secret.Finding
+
+	buf := make([]byte, 0, size+2*overlap)
+	// offset, line and column are the position of buf[0] in the text.
+	// reported is the offset up to which the findings have been reported.
+	offset, line, column, reported := 0, 1, 1, 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		n, err := io.ReadFull(r, buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return nil, err
+		}
+
+		text := string(buf)
+		limit := offset + len(text)
+		if !eof {
+			limit -= overlap
+		}
+
+		found, err := sd.find(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range found {
+			start := offset + f.Start
+			if start < reported || start >= limit {
+				continue
+			}
+			if f.Line == 1 {
+				f.Column += column - 1
+			}
+			f.Line += line - 1
+			f.Start, f.End = start, offset+f.End
+			findings = append(findings, f)
+		}
+		if eof {
+			return findings, nil
+		}
+		reported = limit
+
+		// Keep overlap bytes before the next findings to report, starting at a character.
+		next := reported - overlap - offset
+		for next > 0 && !utf8.RuneStart(buf[next]) {
+			next--
+		}
+		dropped := text[:next]
+		if i := strings.LastIndexByte(dropped, '\n'); i >= 0 {
+			column = utf8.RuneCountInString(dropped[i+1:]) + 1
+		} else {
+			column += utf8.RuneCountInString(dropped)
+		}
+		line += strings.Count(dropped, "\n")
+		offset += next
+		buf = buf[:copy(buf, buf[next:])]
+	}
+}
@@ -0,0 +1,95 @@
+package impl
+
+import (
+	"context"
+	"math"
+	"regexp"
+
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/ikura-hamu/q-cli/pkg/secretcheck"
+)
+
+// EntropyConfig is the configuration of the entropy checker.
+type EntropyConfig struct {
+	// MinLength is the minimum length of a token to be checked.
+	MinLength int
+	// HexThreshold and Base64Threshold are the minimum Shannon entropy, in bits per character,
+	// for a token of each charset to be reported.
+	HexThreshold    float64
+	Base64Threshold float64
+}
+
+var DefaultEntropyConfig = EntropyConfig{
+	MinLength:       20,
+	HexThreshold:    3.0,
+	Base64Threshold: 4.2,
+}
+
+var (
+	hexRegex    = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	uuidRegex   = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	gitSHARegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	// hashes in lockfiles, e.g. "integrity": "sha512-...", go.sum "h1:...", Cargo.lock checksum = "..."
+	hashPrefixRegex  = regexp.MustCompile(`^(?:sha1|sha256|sha384|sha512)-`)
+	hashContextRegex = regexp.MustCompile(`(?i)(?:sha1|sha256|sha384|sha512|h1|checksum|integrity|digest|hash|commit)\W{0,4}$`)
+	digitRegex       = regexp.MustCompile(`[0-9]`)
+	letterRegex      = regexp.MustCompile(`[A-Za-z]`)
+)
+
+// entropyChecker reports random-looking tokens whose Shannon entropy is above the threshold of their charset.
+type entropyChecker struct {
+	checkerInfo
+	conf EntropyConfig
+}
+
+func newEntropyChecker(conf EntropyConfig) entropyChecker {
+	return entropyChecker{checkerInfo{key: secret.EntropyCheckerKey, description: "High-entropy string"}, conf}
+}
+
+func (c entropyChecker) Check(_ context.Context, text string) ([]secretcheck.Finding, error) {
+	var matches []secretcheck.Finding
+	for _, loc := range tokens(text, encodedBytes, max(c.conf.MinLength, 1)) {
+		token := text[loc[0]:loc[1]]
+		if isSafeToken(token, text[max(0, loc[0]-16):loc[0]]) {
+			continue
+		}
+		// Tokens without digits or without letters are words or numbers, not credentials.
+		if !digitRegex.MatchString(token) || !letterRegex.MatchString(token) {
+			continue
+		}
+
+		threshold := c.conf.Base64Threshold
+		if hexRegex.MatchString(token) {
+			threshold = c.conf.HexThreshold
+		}
+		if shannonEntropy(token) >= threshold {
+			matches = append(matches, secretcheck.Finding{Start: loc[0], End: loc[1]})
+		}
+	}
+	return matches, nil
+}
+
+// isSafeToken reports whether token is a well-known non-secret such as a UUID, a git SHA or a hash in a lockfile.
+// before is the text just before the token.
+func isSafeToken(token string, before string) bool {
+	return uuidRegex.MatchString(token) ||
+		gitSHARegex.MatchString(token) ||
+		hashPrefixRegex.MatchString(token) ||
+		hashContextRegex.MatchString(before)
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,299 @@
+package impl
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ikura-hamu/q-cli/internal/config"
+	"github.com/ikura-hamu/q-cli/internal/secret"
+)
+
+// NewSecretDetectorFactory returns a factory of SecretDetector configured by conf.
+// The checkers selected by flags are added to those selected by the config file.
+func NewSecretDetectorFactory(confFactory func() (config.Secret, error), flags config.SecretCheckers) func() (secret.SecretDetector, error) {
+	return func() (secret.SecretDetector, error) {
+		conf, err := confFactory()
+		if err != nil {
+			return nil, fmt.Errorf("create secret config: %w", err)
+		}
+		return NewSecretDetectorFromConfig(conf, flags)
+	}
+}
+
+func NewSecretDetectorFromConfig(conf config.Secret, selections ...config.SecretCheckers) (*SecretDetector, error) {
+	rules, err := loadRules(conf)
+	if err != nil {
+		return nil, err
+	}
+	customRules, err := CustomRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	entropy, err := conf.GetEntropy()
+	if err != nil {
+		return nil, fmt.Errorf("get entropy config: %w", err)
+	}
+	entropyConfig := EntropyConfig{
+		MinLength:       cmp.Or(entropy.MinLength, DefaultEntropyConfig.MinLength),
+		HexThreshold:    cmp.Or(entropy.HexThreshold, DefaultEntropyConfig.HexThreshold),
+		Base64Threshold: cmp.Or(entropy.Base64Threshold, DefaultEntropyConfig.Base64Threshold),
+	}
+
+	var enable, ignore []secret.CheckerKey
+	for _, sel := range append([]config.SecretCheckers{conf}, selections...) {
+		e, err := sel.GetEnable()
+		if err != nil {
+			return nil, fmt.Errorf("get enabled checkers: %w", err)
+		}
+		i, err := sel.GetIgnore()
+		if err != nil {
+			return nil, fmt.Errorf("get ignored checkers: %w", err)
+		}
+		enable = append(enable, checkerKeys(e)...)
+		ignore = append(ignore, checkerKeys(i)...)
+	}
+
+	allowlistPatterns, err := conf.GetAllowlist()
+	if err != nil {
+		return nil, fmt.Errorf("get secret allowlist: %w", err)
+	}
+	allowlist, err := Allowlist(allowlistPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprints, err := loadIgnoreFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	knownSecrets, err := loadKnownSecrets(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	piiOpts, piiKeys, err := piiOptions(conf)
+	if err != nil {
+		return nil, err
+	}
+	enable = append(enable, piiKeys...)
+
+	opts := append([]func(*SecretDetector){customRules, WithEntropyConfig(entropyConfig), KnownSecrets(knownSecrets)}, piiOpts...)
+	// Ignoring wins over enabling, so UseCheckers has to come first.
+	opts = append(opts, UseCheckers(enable), IgnoreCheckers(ignore), allowlist, AllowFingerprints(fingerprints))
+	sd := NewSecretDetector(opts...)
+
+	// Rule keys in the severity settings are resolved after all the checkers are added.
+	if err := applySeverities(sd, conf); err != nil {
+		return nil, err
+	}
+
+	for _, key := range slices.Concat(enable, ignore) {
+		if !sd.known(key) {
+			return nil, fmt.Errorf("unknown secret rule '%s'. See `q secret rules` for the available rules", key)
+		}
+	}
+
+	return sd, nil
+}
+
+// piiOptions returns the options for the PII checkers in the config, and the keys of the checkers to enable.
+func piiOptions(conf config.Secret) ([]func(*SecretDetector), []secret.CheckerKey, error) {
+	pii, err := conf.GetPII()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get pii config: %w", err)
+	}
+
+	var (
+		opts []func(*SecretDetector)
+		keys []secret.CheckerKey
+	)
+	severities := make(map[secret.CheckerKey]secret.Severity, len(pii.Rules))
+	for name, sevStr := range pii.Rules {
+		key, ok := piiCheckerKey(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown pii rule '%s'", name)
+		}
+
+		sev, err := secret.ParseSeverity(sevStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("pii rule '%s': %w", key, err)
+		}
+		severities[key] = sev
+		keys = append(keys, key)
+	}
+	opts = append(opts, WithSeverities(severities))
+
+	if _, ok := severities[secret.InstitutionIDCheckerKey]; ok {
+		if pii.InstitutionIDRegex == "" {
+			return nil, nil, errors.New("pii rule 'institutionID' needs institution_id_regex")
+		}
+	}
+	if pii.InstitutionIDRegex != "" {
+		opt, err := InstitutionID(pii.InstitutionIDRegex)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, opt)
+	}
+
+	return opts, keys, nil
+}
+
+// applySeverities sets the severities of the config file to sd.
+func applySeverities(sd *SecretDetector, conf config.Secret) error {
+	confSeverities, err := conf.GetSeverities()
+	if err != nil {
+		return fmt.Errorf("get secret severities: %w", err)
+	}
+	severities, err := resolveSeverities(sd, confSeverities)
+	if err != nil {
+		return fmt.Errorf("secret.severity: %w", err)
+	}
+
+	confChannels, err := conf.GetChannelSeverities()
+	if err != nil {
+		return fmt.Errorf("get secret channel severities: %w", err)
+	}
+	channels := make(map[string]map[secret.CheckerKey]secret.Severity, len(confChannels))
+	for name, c := range confChannels {
+		channels[name], err = resolveSeverities(sd, c)
+		if err != nil {
+			return fmt.Errorf("secret.channels.%s.severity: %w", name, err)
+		}
+	}
+
+	WithSeverities(severities)(sd)
+	WithChannelSeverities(channels)(sd)
+	return nil
+}
+
+func resolveSeverities(sd *SecretDetector, conf map[string]string) (map[secret.CheckerKey]secret.Severity, error) {
+	severities := make(map[secret.CheckerKey]secret.Severity, len(conf))
+	for name, sevStr := range conf {
+		key := anyChecker
+		if name != string(anyChecker) {
+			var ok bool
+			key, ok = sd.lookup(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown secret rule '%s'", name)
+			}
+		}
+		sev, err := secret.ParseSeverity(sevStr)
+		if err != nil {
+			return nil, fmt.Errorf("rule '%s': %w", name, err)
+		}
+		severities[key] = sev
+	}
+	return severities, nil
+}
+
+// piiCheckerKey finds the PII checker by its key case-insensitively, because the config file does not keep the case of map keys.
+func piiCheckerKey(name string) (secret.CheckerKey, bool) {
+	for _, c := range piiCheckers() {
+		if strings.EqualFold(string(c.Key()), name) {
+			return c.Key(), true
+		}
+	}
+	return "", false
+}
+
+// loadKnownSecrets collects the secret values from the config, environment variables and files.
+func loadKnownSecrets(conf config.Secret) ([]string, error) {
+	values, err := conf.GetKnownSecrets()
+	if err != nil {
+		return nil, fmt.Errorf("get known secrets: %w", err)
+	}
+	values = append(values, EnvSecrets(os.Environ())...)
+
+	files, err := conf.GetKnownSecretFiles()
+	if err != nil {
+		return nil, fmt.Errorf("get known secret files: %w", err)
+	}
+	for _, path := range files {
+		v, err := LoadKnownSecretsFile(path)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v...)
+	}
+
+	return values, nil
+}
+
+// loadIgnoreFiles reads the fingerprints in the default ignore files.
+func loadIgnoreFiles() ([]string, error) {
+	paths, err := DefaultIgnoreFilePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	var fingerprints []string
+	for _, path := range paths {
+		fps, err := NewIgnoreFile(path).Load()
+		if err != nil {
+			return nil, err
+		}
+		fingerprints = append(fingerprints, fps...)
+	}
+	return fingerprints, nil
+}
+
+func checkerKeys(keys []string) []secret.CheckerKey {
+	res := make([]secret.CheckerKey, 0, len(keys))
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); k != "" {
+			res = append(res, secret.CheckerKey(k))
+		}
+	}
+	return res
+}
+
+// loadRules collects the custom rules from the config file, gitleaks configs and git-secrets.
+func loadRules(conf config.Secret) ([]secret.Rule, error) {
+	confRules, err := conf.GetRules()
+	if err != nil {
+		return nil, fmt.Errorf("get secret rules: %w", err)
+	}
+	rules := make([]secret.Rule, 0, len(confRules))
+	for _, r := range confRules {
+		rules = append(rules, secret.Rule{
+			ID: r.ID, Description: r.Description, Regex: r.Regex, SecretGroup: r.SecretGroup,
+			Keywords: r.Keywords, Allowlist: r.Allowlist, Severity: r.Severity,
+		})
+	}
+
+	gitleaksConfigs, err := conf.GetGitleaksConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("get gitleaks configs: %w", err)
+	}
+	for _, path := range gitleaksConfigs {
+		r, err := LoadGitleaksRules(path)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r...)
+	}
+
+	gitSecrets, err := conf.GetGitSecrets()
+	if err != nil {
+		return nil, fmt.Errorf("get git-secrets flag: %w", err)
+	}
+	if gitSecrets {
+		r, skipped, err := LoadGitSecretsPatterns()
+		if err != nil {
+			return nil, err
+		}
+		for _, err := range skipped {
+			fmt.Fprintf(os.Stderr, "Warning: skipped %v\n", err)
+		}
+		rules = append(rules, r...)
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,70 @@
+package impl
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// gitleaksConfig is the part of a gitleaks config (https://github.com/gitleaks/gitleaks) used by q.
+type gitleaksConfig struct {
+	Rules []struct {
+		ID          string            `toml:"id"`
+		Description string            `toml:"description"`
+		Regex       string            `toml:"regex"`
+		SecretGroup int               `toml:"secretGroup"`
+		Keywords    []string          `toml:"keywords"`
+		Allowlist   gitleaksAllowlist `toml:"allowlist"`
+		// Allowlists is the newer form of Allowlist since gitleaks v8.21.0.
+		Allowlists []gitleaksAllowlist `toml:"allowlists"`
+	} `toml:"rules"`
+	Allowlist gitleaksAllowlist `toml:"allowlist"`
+}
+
+type gitleaksAllowlist struct {
+	Regexes []string `toml:"regexes"`
+}
+
+// gitleaksRulePrefix namespaces the IDs of gitleaks rules,
+// so that a config such as the official one can have the same IDs as the built-in rules (e.g. jwt).
+const gitleaksRulePrefix = "gitleaks:"
+
+// LoadGitleaksRules reads the rules of a gitleaks TOML config. The IDs are prefixed with "gitleaks:".
+// Rules without regex, such as path-only rules, are skipped.
+func LoadGitleaksRules(path string) ([]secret.Rule, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read gitleaks config: %w", err)
+	}
+
+	var conf gitleaksConfig
+	if err := toml.Unmarshal(b, &conf); err != nil {
+		return nil, fmt.Errorf("parse gitleaks config '%s': %w", path, err)
+	}
+
+	rules := make([]secret.Rule, 0, len(conf.Rules))
+	for _, r := range conf.Rules {
+		if r.Regex == "" {
+			continue
+		}
+
+		allowlist := append([]string{}, conf.Allowlist.Regexes...)
+		allowlist = append(allowlist, r.Allowlist.Regexes...)
+		for _, a := range r.Allowlists {
+			allowlist = append(allowlist, a.Regexes...)
+		}
+
+		rules = append(rules, secret.Rule{
+			ID:          gitleaksRulePrefix + r.ID,
+			Description: r.Description,
+			Regex:       r.Regex,
+			SecretGroup: r.SecretGroup,
+			Keywords:    r.Keywords,
+			Allowlist:   allowlist,
+		})
+	}
+
+	return rules, nil
+}
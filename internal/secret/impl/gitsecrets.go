@@ -0,0 +1,76 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/ikura-hamu/q-cli/internal/secret"
+)
+
+// LoadGitSecretsPatterns reads the patterns registered to git-secrets (https://github.com/awslabs/git-secrets)
+// by `git config secrets.patterns`. `secrets.allowed` is matched against the line of every match, as git-secrets does.
+// If git is not installed or nothing is registered, it returns no rules.
+//
+// git-secrets uses POSIX extended regexes, which Go regexes do not fully support, e.g. backreferences.
+// Such patterns are skipped, and the reasons are returned as skipped.
+func LoadGitSecretsPatterns() (rules []secret.Rule, skipped []error, err error) {
+	patterns, err := gitConfigAll("secrets.patterns")
+	if err != nil {
+		return nil, nil, err
+	}
+	allowed, err := gitConfigAll("secrets.allowed")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var lineAllowlist []string
+	for _, a := range allowed {
+		if _, err := regexp.Compile(a); err != nil {
+			skipped = append(skipped, fmt.Errorf("git-secrets allowed pattern '%s': %w", a, err))
+			continue
+		}
+		lineAllowlist = append(lineAllowlist, a)
+	}
+
+	rules = make([]secret.Rule, 0, len(patterns))
+	for i, p := range patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			skipped = append(skipped, fmt.Errorf("git-secrets pattern '%s': %w", p, err))
+			continue
+		}
+		rules = append(rules, secret.Rule{
+			ID:            fmt.Sprintf("git-secrets-%d", i+1),
+			Description:   fmt.Sprintf("git-secrets pattern '%s'", p),
+			Regex:         p,
+			LineAllowlist: lineAllowlist,
+		})
+	}
+
+	return rules, skipped, nil
+}
+
+func gitConfigAll(key string) ([]string, error) {
+	out, err := exec.Command("git", "config", "--get-all", key).Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, nil
+	}
+	var exitErr *exec.ExitError
+	// git config exits with 1 when the key is not set.
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("git config --get-all %s: %w", key, err)
+	}
+
+	var values []string
+	for _, l := range strings.Split(string(out), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			values = append(values, l)
+		}
+	}
+	return values, nil
+}
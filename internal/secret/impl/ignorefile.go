@@ -0,0 +1,130 @@
+package impl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/ras0q/goalie"
+)
+
+// IgnoreFileName is the name of the file storing the fingerprints of allowed findings.
+const IgnoreFileName = ".q-secretignore"
+
+type IgnoreFile struct {
+	path string
+}
+
+var _ secret.IgnoreFile = (*IgnoreFile)(nil)
+
+func NewIgnoreFile(path string) *IgnoreFile {
+	return &IgnoreFile{path: path}
+}
+
+// DefaultIgnoreFilePaths returns the ignore files read by default:
+// the one in the home directory and the one in the current directory.
+func DefaultIgnoreFilePaths() ([]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("get home directory: %w", err)
+	}
+	paths := []string{filepath.Join(home, IgnoreFileName)}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("get current directory: %w", err)
+	}
+	if wd != home {
+		paths = append(paths, filepath.Join(wd, IgnoreFileName))
+	}
+	return paths, nil
+}
+
+// NewDefaultIgnoreFileFactory returns a factory of the ignore file in the home directory.
+func NewDefaultIgnoreFileFactory() func() (secret.IgnoreFile, error) {
+	return func() (secret.IgnoreFile, error) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("get home directory: %w", err)
+		}
+		return NewIgnoreFile(filepath.Join(home, IgnoreFileName)), nil
+	}
+}
+
+func (f *IgnoreFile) Path() string {
+	return f.path
+}
+
+// Load returns the fingerprints in the file. A missing file has no fingerprints.
+// Empty lines and lines starting with "#" are skipped, and anything after a fingerprint is a comment.
+func (f *IgnoreFile) Load() (fingerprints []string, err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	file, err := os.Open(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", f.path, err)
+	}
+	defer g.Guard(file.Close)
+
+	sc := bufio.NewScanner(file)
+	for i := 1; sc.Scan(); i++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fp := strings.Fields(line)[0]
+		if !secret.ValidFingerprint(fp) {
+			return nil, fmt.Errorf("%s:%d: invalid fingerprint '%s'", f.path, i, fp)
+		}
+		fingerprints = append(fingerprints, fp)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", f.path, err)
+	}
+
+	return fingerprints, nil
+}
+
+func (f *IgnoreFile) Add(fingerprints []string) (err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	for _, fp := range fingerprints {
+		if !secret.ValidFingerprint(fp) {
+			return fmt.Errorf("invalid fingerprint '%s'", fp)
+		}
+	}
+
+	existing, err := f.Load()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.path, err)
+	}
+	defer g.Guard(file.Close)
+
+	for _, fp := range fingerprints {
+		if slices.Contains(existing, fp) {
+			continue
+		}
+		if _, err := fmt.Fprintln(file, fp); err != nil {
+			return fmt.Errorf("write %s: %w", f.path, err)
+		}
+		existing = append(existing, fp)
+	}
+
+	return nil
+}
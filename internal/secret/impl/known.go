@@ -0,0 +1,129 @@
+package impl
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/ikura-hamu/q-cli/pkg/secretcheck"
+	"github.com/ras0q/goalie"
+)
+
+// minKnownSecretLength is the minimum length of a known secret value.
+// Shorter values like "1" or "true" would match almost every message.
+const minKnownSecretLength = 8
+
+// KnownSecrets returns an option making the known secret checker report the values and their encoded forms.
+func KnownSecrets(values []string) func(sd *SecretDetector) {
+	return func(sd *SecretDetector) {
+		sd.replace(newKnownSecretChecker(values))
+	}
+}
+
+// knownSecretChecker reports the values as they are, base64-encoded and URL-encoded.
+type knownSecretChecker struct {
+	checkerInfo
+	patterns []string
+}
+
+func newKnownSecretChecker(values []string) knownSecretChecker {
+	var patterns []string
+	for _, v := range values {
+		if len(v) < minKnownSecretLength {
+			continue
+		}
+		patterns = append(patterns,
+			v,
+			base64.StdEncoding.EncodeToString([]byte(v)),
+			base64.RawStdEncoding.EncodeToString([]byte(v)),
+			base64.URLEncoding.EncodeToString([]byte(v)),
+			base64.RawURLEncoding.EncodeToString([]byte(v)),
+			url.QueryEscape(v),
+			url.PathEscape(v),
+		)
+	}
+	slices.Sort(patterns)
+	patterns = slices.Compact(patterns)
+
+	return knownSecretChecker{
+		checkerInfo{key: secret.KnownSecretCheckerKey, description: "Known secret value from the config, environment variables or files"},
+		patterns,
+	}
+}
+
+func (c knownSecretChecker) Check(_ context.Context, text string) ([]secretcheck.Finding, error) {
+	var matches []secretcheck.Finding
+	for _, p := range c.patterns {
+		for offset := 0; ; {
+			i := strings.Index(text[offset:], p)
+			if i < 0 {
+				break
+			}
+			matches = append(matches, secretcheck.Finding{Start: offset + i, End: offset + i + len(p)})
+			offset += i + len(p)
+		}
+	}
+	return matches, nil
+}
+
+var secretEnvNameRegex = regexp.MustCompile(`^[A-Za-z0-9_]+_(?:TOKEN|SECRET|PASSWORD|KEY)$`)
+
+// EnvSecrets returns the values of the environment variables named like *_TOKEN, *_SECRET, *_PASSWORD or *_KEY.
+// environ is in the form of os.Environ.
+func EnvSecrets(environ []string) []string {
+	var values []string
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if ok && value != "" && secretEnvNameRegex.MatchString(strings.ToUpper(name)) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+var envLineRegex = regexp.MustCompile(`^(?:export\s+)?[A-Za-z_][A-Za-z0-9_]*=(.*)$`)
+
+// LoadKnownSecretsFile reads secret values from a file, one per line.
+// Lines in the form of NAME=value, like a .env file, contribute the value.
+// Empty lines and lines starting with "#" are skipped.
+func LoadKnownSecretsFile(path string) (values []string, err error) {
+	g := goalie.New()
+	defer g.Collect(&err)
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("known secrets file '%s' not found", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open known secrets file: %w", err)
+	}
+	defer g.Guard(f.Close)
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := envLineRegex.FindStringSubmatch(line); m != nil {
+			line = strings.Trim(strings.TrimSpace(m[1]), `"'`)
+		}
+		if line != "" {
+			values = append(values, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read known secrets file '%s': %w", path, err)
+	}
+
+	return values, nil
+}
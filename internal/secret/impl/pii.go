@@ -0,0 +1,128 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/ikura-hamu/q-cli/pkg/secretcheck"
+)
+
+var (
+	emailRegex = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	// phoneJPRegex matches domestic numbers like 03-1234-5678 or 09012345678 and international ones like +81 90 1234 5678.
+	phoneJPRegex    = regexp.MustCompile(`(?:\+81[-\s]?|0)\d{1,4}[-\s]?\d{1,4}[-\s]?\d{4}`)
+	creditCardRegex = regexp.MustCompile(`\d(?:[ -]?\d){12,18}`)
+	myNumberRegex   = regexp.MustCompile(`\d{4}[ -]?\d{4}[ -]?\d{4}`)
+)
+
+// piiCheckers are opt-in checkers of personal data. They warn by default.
+func piiCheckers() []secretcheck.Checker {
+	return []secretcheck.Checker{
+		regexChecker{checkerInfo{key: secret.EmailCheckerKey, description: "Email address", keywords: []string{"@"}}, emailRegex, 0},
+		digitsChecker{checkerInfo{key: secret.PhoneJPCheckerKey, description: "Japanese phone number"}, phoneJPRegex, validPhoneJP},
+		digitsChecker{checkerInfo{key: secret.CreditCardCheckerKey, description: "Credit card number"}, creditCardRegex, validCreditCard},
+		digitsChecker{checkerInfo{key: secret.MyNumberCheckerKey, description: "My Number (Japanese individual number)"}, myNumberRegex, validMyNumber},
+		newInstitutionIDChecker(nil),
+	}
+}
+
+// newInstitutionIDChecker returns the checker of the institution IDs matching re. It reports nothing if re is nil.
+func newInstitutionIDChecker(re *regexp.Regexp) regexChecker {
+	return regexChecker{checkerInfo{key: secret.InstitutionIDCheckerKey, description: "Student or staff ID"}, re, 0}
+}
+
+// InstitutionID returns an option making the institution ID checker report matches of the regex,
+// e.g. student or staff IDs of a university.
+func InstitutionID(pattern string) (func(sd *SecretDetector), error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid institution ID regex: %w", err)
+	}
+	return func(sd *SecretDetector) {
+		sd.replace(newInstitutionIDChecker(re))
+	}, nil
+}
+
+// digitsChecker reports matches of re which are not a part of a longer number and whose digits pass valid.
+type digitsChecker struct {
+	checkerInfo
+	re    *regexp.Regexp
+	valid func(digits string) bool
+}
+
+func (c digitsChecker) Check(_ context.Context, text string) ([]secretcheck.Finding, error) {
+	var matches []secretcheck.Finding
+	for _, loc := range c.re.FindAllStringIndex(text, -1) {
+		if loc[0] > 0 && isDigit(text[loc[0]-1]) || loc[1] < len(text) && isDigit(text[loc[1]]) {
+			continue
+		}
+		if c.valid(digitsOf(text[loc[0]:loc[1]])) {
+			matches = append(matches, secretcheck.Finding{Start: loc[0], End: loc[1]})
+		}
+	}
+	return matches, nil
+}
+
+func validPhoneJP(digits string) bool {
+	if strings.HasPrefix(digits, "81") {
+		digits = "0" + digits[2:]
+	}
+	return len(digits) == 10 || len(digits) == 11 && digits[1] != '0'
+}
+
+func validCreditCard(digits string) bool {
+	return strings.Trim(digits, digits[:1]) != "" && luhn(digits)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func digitsOf(s string) string {
+	return strings.Map(func(r rune) rune {
+		if '0' <= r && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// luhn validates the check digit of a card number.
+func luhn(digits string) bool {
+	sum := 0
+	for i := range len(digits) {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// validMyNumber validates the check digit of a 12-digit My Number.
+func validMyNumber(digits string) bool {
+	if len(digits) != 12 {
+		return false
+	}
+	sum := 0
+	for n := 1; n <= 11; n++ {
+		p := int(digits[11-n] - '0')
+		q := n + 1
+		if n >= 7 {
+			q = n - 5
+		}
+		sum += p * q
+	}
+	check := 0
+	if r := sum % 11; r > 1 {
+		check = 11 - r
+	}
+	return int(digits[11]-'0') == check
+}
@@ -0,0 +1,109 @@
+package impl
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/ikura-hamu/q-cli/pkg/secretcheck"
+)
+
+// regexChecker reports the matches of re.
+// If group is not 0, the span of the submatch is reported instead of the whole match,
+// so that redaction keeps the surrounding context such as the key name.
+type regexChecker struct {
+	checkerInfo
+	// re is nil for a checker with nothing to report until it is configured, e.g. the institution ID checker.
+	re    *regexp.Regexp
+	group int
+}
+
+func (c regexChecker) Check(_ context.Context, text string) ([]secretcheck.Finding, error) {
+	return c.find(text), nil
+}
+
+func (c regexChecker) find(text string) []secretcheck.Finding {
+	if c.re == nil {
+		return nil
+	}
+	var matches []secretcheck.Finding
+	for _, m := range c.re.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2*c.group], m[2*c.group+1]
+		if start < 0 {
+			continue
+		}
+		matches = append(matches, secretcheck.Finding{Start: start, End: end})
+	}
+	return matches
+}
+
+var (
+	awsAccessKeyRegex      = regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA|A3T[A-Z0-9])[A-Z0-9]{16}\b`)
+	awsSecretKeyRegex      = regexp.MustCompile(`(?i)aws_?(?:secret_?access_?key|secret_?key)["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`)
+	gcpServiceAccountRegex = regexp.MustCompile(`"private_key_id"\s*:\s*"([0-9a-f]{40})"`)
+	slackTokenRegex        = regexp.MustCompile(`\bxox[abposr]-[0-9A-Za-z-]{10,}|\bxapp-\d-[A-Z0-9]+-\d+-[a-z0-9]+`)
+	slackWebhookRegex      = regexp.MustCompile(`https://hooks\.slack\.com/(?:services|workflows|triggers)/[A-Za-z0-9_/+-]{20,}`)
+	discordBotTokenRegex   = regexp.MustCompile(`\b[MNO][A-Za-z\d_-]{23,25}\.[A-Za-z\d_-]{6}\.[A-Za-z\d_-]{27,38}\b`)
+	discordWebhookRegex    = regexp.MustCompile(`https://(?:ptb\.|canary\.)?discord(?:app)?\.com/api/webhooks/\d+/[A-Za-z0-9_-]+`)
+	stripeRegex            = regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[0-9a-zA-Z]{24,99}\b`)
+	npmRegex               = regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`)
+	pypiRegex              = regexp.MustCompile(`pypi-AgEIcHlwaS5vcmc[A-Za-z0-9_-]{50,}`)
+	gitlabRegex            = regexp.MustCompile(`\b(?:glpat|gldt|glrt|glptt|glsoat|glcbt|glimt|glagent|gloas)-[0-9A-Za-z_-]{20,}|\bGR1348941[0-9A-Za-z_-]{20,}`)
+	openAIRegex            = regexp.MustCompile(`\bsk-(?:proj|svcacct|admin)-[A-Za-z0-9_-]{40,}|\bsk-[A-Za-z0-9]{20}T3BlbkFJ[A-Za-z0-9]{20}\b`)
+	anthropicRegex         = regexp.MustCompile(`\bsk-ant-(?:api|admin)\d{2}-[A-Za-z0-9_-]{80,}`)
+	traQBotTokenRegex      = regexp.MustCompile(`(?i)(?:bot_?access_?token|traq_?(?:bot_?)?token|authorization:\s*bearer)["']?\s*[:=]?\s*["']?([a-z0-9]{36})\b`)
+	jwtRegex               = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)
+	databaseURLRegex       = regexp.MustCompile(`\b(?:postgres(?:ql)?|mysql|mariadb|mongodb(?:\+srv)?|rediss?|amqps?|mssql|sqlserver)://[^:@/\s]+:([^@/\s$][^@/\s]*)@`)
+)
+
+// tokenChecker is like regexChecker for a regex without a keyword. re only runs on the runs of bytes in the set
+// that are at least minLength bytes long, which is much faster than running it on the whole text.
+// The bytes around a run must not be able to change the matches of re, e.g. by \b.
+type tokenChecker struct {
+	checkerInfo
+	re        *regexp.Regexp
+	set       *byteSet
+	minLength int
+}
+
+func (c tokenChecker) Check(_ context.Context, text string) ([]secretcheck.Finding, error) {
+	find := regexChecker{re: c.re}
+	var matches []secretcheck.Finding
+	for _, t := range tokens(text, c.set, c.minLength) {
+		for _, m := range find.find(text[t[0]:t[1]]) {
+			matches = append(matches, secretcheck.Finding{Start: m.Start + t[0], End: m.End + t[0]})
+		}
+	}
+	return matches, nil
+}
+
+// discordTokenBytes are the bytes that can be in a Discord bot token.
+var discordTokenBytes = newByteSet(func(b byte) bool {
+	return isEncodedByte(b) && b != '+' && b != '/' || b == '.'
+})
+
+var githubTokensRegex = regexp.MustCompile(`(ghu|ghs|gho|ghp|ghr)_[0-9a-zA-Z]{36}|github_pat_[0-9a-zA-Z_]{82}`)
+
+var privateKeysRegex = regexp.MustCompile(`\-\-\-\-\-BEGIN PRIVATE KEY\-\-\-\-\-|\-\-\-\-\-BEGIN RSA PRIVATE KEY\-\-\-\-\-|\-\-\-\-\-BEGIN OPENSSH PRIVATE KEY\-\-\-\-\-|\-\-\-\-\-BEGIN PGP PRIVATE KEY BLOCK\-\-\-\-\-|\-\-\-\-\-BEGIN DSA PRIVATE KEY\-\-\-\-\-|\-\-\-\-\-BEGIN EC PRIVATE KEY\-\-\-\-\-`)
+
+var privateKeysEndRegex = regexp.MustCompile(`\-\-\-\-\-END [A-Z ]*PRIVATE KEY( BLOCK)?\-\-\-\-\-`)
+
+// privateKeyChecker reports the whole key block from the BEGIN line to the END line,
+// or to the end of the text when the END line is missing.
+type privateKeyChecker struct {
+	checkerInfo
+}
+
+func (c privateKeyChecker) Check(_ context.Context, text string) ([]secretcheck.Finding, error) {
+	var matches []secretcheck.Finding
+	for _, loc := range privateKeysRegex.FindAllStringIndex(text, -1) {
+		if len(matches) > 0 && loc[0] < matches[len(matches)-1].End {
+			continue
+		}
+		end := len(text)
+		if endLoc := privateKeysEndRegex.FindStringIndex(text[loc[1]:]); endLoc != nil {
+			end = loc[1] + endLoc[1]
+		}
+		matches = append(matches, secretcheck.Finding{Start: loc[0], End: end})
+	}
+	return matches, nil
+}
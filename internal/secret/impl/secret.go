@@ -1,57 +1,239 @@
 package impl
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"maps"
 	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/ikura-hamu/q-cli/internal/secret"
+	"github.com/ikura-hamu/q-cli/pkg/secretcheck"
 )
 
 type SecretDetector struct {
-	checkers map[secret.CheckerKey]checker
+	checkers map[secret.CheckerKey]secret.Checker
+	// others are the inactive checkers that can be enabled by UseCheckers.
+	others map[secret.CheckerKey]secret.Checker
+	// allowlist and fingerprints are the findings to ignore.
+	allowlist    []*regexp.Regexp
+	fingerprints map[string]struct{}
+	// severities and channelSeverities override the severity of the checkers. See severity.
+	severities        map[secret.CheckerKey]secret.Severity
+	channelSeverities map[string]map[secret.CheckerKey]secret.Severity
+	// defaultSeverities are the severities of the registered checkers which do not block by default.
+	defaultSeverities map[secret.CheckerKey]secret.Severity
+	// channel is the channel the message is sent to, in lower case. Empty means the default channel.
+	channel string
+	// prefilter finds where the active checkers with keywords need to run.
+	prefilter *prefilter
 }
 
+// NewSecretDetector returns a detector composed of the checkers in secretcheck.DefaultRegistry.
 func NewSecretDetector(opts ...func(sd *SecretDetector)) *SecretDetector {
+	return NewSecretDetectorWithRegistry(secretcheck.DefaultRegistry, opts...)
+}
+
+// NewSecretDetectorWithRegistry returns a detector composed of the checkers in the registry.
+// Opt-in checkers are inactive until they are enabled by UseCheckers.
+func NewSecretDetectorWithRegistry(registry *secretcheck.Registry, opts ...func(sd *SecretDetector)) *SecretDetector {
 	sd := &SecretDetector{
-		checkers: defaultCheckers,
+		checkers:          make(map[secret.CheckerKey]secret.Checker),
+		others:            make(map[secret.CheckerKey]secret.Checker),
+		fingerprints:      make(map[string]struct{}),
+		severities:        make(map[secret.CheckerKey]secret.Severity),
+		channelSeverities: make(map[string]map[secret.CheckerKey]secret.Severity),
+		defaultSeverities: make(map[secret.CheckerKey]secret.Severity),
+	}
+	for _, reg := range registry.Registrations() {
+		key := reg.Checker.Key()
+		if reg.OptIn {
+			sd.others[key] = reg.Checker
+		} else {
+			sd.checkers[key] = reg.Checker
+		}
+		if reg.Severity != secret.SeverityBlock {
+			sd.defaultSeverities[key] = reg.Severity
+		}
 	}
 
 	for _, opt := range opts {
 		opt(sd)
 	}
+	sd.prefilter = newPrefilter(sd.checkers)
 	return sd
 }
 
-func (sd *SecretDetector) Detect(ctx context.Context, message string) error {
-	for _, checker := range sd.checkers {
-		ok, err := checker.f(message)
-		if err != nil {
-			return fmt.Errorf("failed to check secret: %w", err)
-		}
-		if ok {
-			return secret.NewErrSecretDetected(checker.message)
+func (sd *SecretDetector) Checkers() []secret.CheckerInfo {
+	infos := make([]secret.CheckerInfo, 0, len(sd.checkers)+len(sd.others))
+	for key, c := range sd.checkers {
+		infos = append(infos, secret.CheckerInfo{Key: key, Description: c.Description(), Severity: sd.severity(key), Active: true})
+	}
+	for key, c := range sd.others {
+		infos = append(infos, secret.CheckerInfo{Key: key, Description: c.Description(), Severity: sd.severity(key), Active: false})
+	}
+	slices.SortFunc(infos, func(a, b secret.CheckerInfo) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return infos
+}
+
+// ForChannel returns the detector using the severities of the channel.
+func (sd *SecretDetector) ForChannel(channel string) secret.SecretDetector {
+	c := *sd
+	c.channel = strings.ToLower(channel)
+	return &c
+}
+
+// lookup finds the checker by its key. When there is no exact match, the key is compared case-insensitively,
+// because the config file does not keep the case of map keys.
+func (sd *SecretDetector) lookup(name string) (secret.CheckerKey, bool) {
+	if sd.known(secret.CheckerKey(name)) {
+		return secret.CheckerKey(name), true
+	}
+	for _, c := range sd.Checkers() {
+		if strings.EqualFold(string(c.Key), name) {
+			return c.Key, true
 		}
 	}
+	return "", false
+}
+
+// known reports whether the detector has the checker, active or not.
+func (sd *SecretDetector) known(key secret.CheckerKey) bool {
+	_, active := sd.checkers[key]
+	_, inactive := sd.others[key]
+	return active || inactive
+}
+
+func (sd *SecretDetector) Detect(ctx context.Context, message string) error {
+	findings, err := sd.find(ctx, message)
+	if err != nil {
+		return err
+	}
+	if len(findings) > 0 {
+		return secret.NewErrSecretDetected(findings)
+	}
 
 	return nil
 }
 
-var githubTokensRegex = regexp.MustCompile(`(ghu|ghs|gho|ghp|ghr)_[0-9a-zA-Z]{36}|github_pat_[0-9a-zA-Z_]{82}`)
+func (sd *SecretDetector) Redact(ctx context.Context, message string) (string, []secret.Redaction, error) {
+	findings, err := sd.find(ctx, message)
+	if err != nil {
+		return "", nil, err
+	}
 
-func github(message string) (bool, error) {
+	sb := &strings.Builder{}
+	fingerprints := make(map[secret.CheckerKey][]string)
+	last := 0
+	for _, f := range findings {
+		// When findings overlap, the one starting first (and then the longer one) wins.
+		if f.Start < last {
+			continue
+		}
+		sb.WriteString(message[last:f.Start])
+		fmt.Fprintf(sb, "[REDACTED:%s]", f.Key)
+		last = f.End
+		fingerprints[f.Key] = append(fingerprints[f.Key], f.Fingerprint)
+	}
+	sb.WriteString(message[last:])
 
-	if githubTokensRegex.MatchString(message) {
-		return true, nil
+	redactions := make([]secret.Redaction, 0, len(fingerprints))
+	for _, key := range slices.Sorted(maps.Keys(fingerprints)) {
+		redactions = append(redactions, secret.Redaction{Key: key, Count: len(fingerprints[key]), Fingerprints: fingerprints[key]})
 	}
-	return false, nil
+
+	return sb.String(), redactions, nil
+}
+
+// find runs every checker and returns the findings sorted by position, except the allowed ones.
+// Findings at the same position are sorted by the longer one first, then by the checker key.
+// Checkers with keywords only run around the keywords found by the prefilter.
+// Encoded blobs in the message are decoded and checked too. See findEncoded.
+func (sd *SecretDetector) find(ctx context.Context, message string) ([]secret.Finding, error) {
+	return sd.findIn(ctx, message, maxDecodeDepth)
 }
 
-var privateKeysRegex = regexp.MustCompile(`\-\-\-\-\-BEGIN PRIVATE KEY\-\-\-\-\-|\-\-\-\-\-BEGIN RSA PRIVATE KEY\-\-\-\-\-|\-\-\-\-\-BEGIN OPENSSH PRIVATE KEY\-\-\-\-\-|\-\-\-\-\-BEGIN PGP PRIVATE KEY BLOCK\-\-\-\-\-|\-\-\-\-\-BEGIN DSA PRIVATE KEY\-\-\-\-\-|\-\-\-\-\-BEGIN EC PRIVATE KEY\-\-\-\-\-`)
+func (sd *SecretDetector) findIn(ctx context.Context, message string, depth int) ([]secret.Finding, error) {
+	windows, err := sd.prefilter.windows(ctx, message, sd.checkers)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []secret.Finding
+	for _, key := range slices.Sorted(maps.Keys(sd.checkers)) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		c := sd.checkers[key]
+		found, err := sd.locate(ctx, message, c, windows[key])
+		if err != nil {
+			return nil, fmt.Errorf("failed to check secret (%s): %w", key, err)
+		}
+		for _, m := range found {
+			if m.Start < 0 || m.End < m.Start || m.End > len(message) {
+				return nil, fmt.Errorf("failed to check secret (%s): invalid range [%d, %d)", key, m.Start, m.End)
+			}
+			line, column := position(message, m.Start)
+			f := secret.Finding{
+				Key:         key,
+				Description: cmp.Or(m.Description, c.Description()),
+				Severity:    sd.severity(key),
+				Line:        line,
+				Column:      column,
+				Excerpt:     mask(message[m.Start:m.End]),
+				Fingerprint: secret.Fingerprint(key, message[m.Start:m.End]),
+				Start:       m.Start,
+				End:         m.End,
+			}
+			if sd.allowed(message, f) {
+				continue
+			}
+			findings = append(findings, f)
+		}
+	}
 
-func privateKey(message string) (bool, error) {
-	if privateKeysRegex.MatchString(message) {
-		return true, nil
+	if depth > 0 {
+		encoded, err := sd.findEncoded(ctx, message, depth)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range encoded {
+			// A checker may know the encoded form itself, e.g. knownSecret.
+			// A blob may also have the secret as is, e.g. a URL with another parameter percent-encoded,
+			// and then the plain finding is more precise.
+			if !slices.ContainsFunc(findings, func(g secret.Finding) bool {
+				return g.Key == f.Key && (g.Start == f.Start && g.End == f.End ||
+					g.Fingerprint == f.Fingerprint && f.Start <= g.Start && g.End <= f.End)
+			}) {
+				findings = append(findings, f)
+			}
+		}
 	}
-	return false, nil
+
+	slices.SortStableFunc(findings, func(a, b secret.Finding) int {
+		return cmp.Or(cmp.Compare(a.Start, b.Start), cmp.Compare(b.End, a.End), cmp.Compare(a.Key, b.Key))
+	})
+
+	return findings, nil
+}
+
+// position returns the 1-based line and column (in characters) of the byte offset.
+func position(message string, offset int) (int, int) {
+	before := message[:offset]
+	line := strings.Count(before, "\n") + 1
+	lineStart := strings.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCountInString(before[lineStart:]) + 1
+}
+
+// mask hides most of the first line of a secret so that findings can be printed safely.
+func mask(s string) string {
+	first, _, _ := strings.Cut(s, "\n")
+	runes := []rune(strings.TrimSpace(first))
+	keep := min(len(runes)/4, 6)
+	return string(runes[:keep]) + strings.Repeat("*", 8)
 }
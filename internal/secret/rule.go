@@ -0,0 +1,19 @@
+package secret
+
+// Rule is a user-defined secret rule, from the config file, a gitleaks config or git-secrets.
+type Rule struct {
+	ID          string
+	Description string
+	Regex       string
+	// SecretGroup is the regex group reported as the secret. 0 means the whole match.
+	SecretGroup int
+	// Keywords limit the rule to the text around any of them, case-insensitively.
+	// If empty, the rule is applied to the whole text.
+	Keywords []string
+	// Allowlist is regexes of matches which are not secrets.
+	Allowlist []string
+	// LineAllowlist is regexes of lines whose matches are not secrets, like `secrets.allowed` of git-secrets.
+	LineAllowlist []string
+	// Severity is "warn", "confirm" or "block". Empty means "block".
+	Severity string
+}
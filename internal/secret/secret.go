@@ -2,10 +2,63 @@ package secret
 
 import (
 	"context"
+	"io"
+	"slices"
+	"strings"
 )
 
 //go:generate go run github.com/matryer/moq -pkg mock -out mock/${GOFILE}.go . SecretDetector
 
 type SecretDetector interface {
 	Detect(ctx context.Context, message string) error
+	// DetectReader is like Detect, but reads the text from r in chunks, so that a large text is not kept in memory.
+	DetectReader(ctx context.Context, r io.Reader) error
+	// Redact replaces every detected secret with "[REDACTED:<checker key>]".
+	Redact(ctx context.Context, message string) (string, []Redaction, error)
+	// Checkers returns every checker the detector knows, sorted by key.
+	Checkers() []CheckerInfo
+	// ForChannel returns the detector using the severities configured for the channel.
+	// An empty channel is the default channel of the webhook.
+	ForChannel(channel string) SecretDetector
+}
+
+// CheckerInfo describes a checker and whether it is used for detection.
+type CheckerInfo struct {
+	Key         CheckerKey
+	Description string
+	Severity    Severity
+	Active      bool
+}
+
+// Finding is a secret found in a message.
+type Finding struct {
+	Key         CheckerKey `json:"rule"`
+	Description string     `json:"description"`
+	Severity    Severity   `json:"severity"`
+	// Line and Column are 1-based. Column is counted in characters.
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// Excerpt is the masked matched text. The secret itself is never kept in a Finding.
+	Excerpt string `json:"excerpt"`
+	// Fingerprint can be added to the ignore file to allow this finding.
+	Fingerprint string `json:"fingerprint"`
+	// Encoding is the encodings the secret was hidden in, outermost first, e.g. ["base64"].
+	// Line, Column, Start and End are of the encoded blob then.
+	Encoding []string `json:"encoding,omitempty"`
+	// Start and End are the byte offsets of the match in the message.
+	Start int `json:"-"`
+	End   int `json:"-"`
+}
+
+// Redaction is the number of secrets masked by a checker.
+type Redaction struct {
+	Key   CheckerKey
+	Count int
+	// Fingerprints are the fingerprints of the masked secrets.
+	Fingerprints []string
+}
+
+// Rule returns the checker key with the encoding path, e.g. "base64 → github".
+func (f Finding) Rule() string {
+	return strings.Join(append(slices.Clone(f.Encoding), string(f.Key)), " → ")
 }
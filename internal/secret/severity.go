@@ -0,0 +1,16 @@
+package secret
+
+import "github.com/ikura-hamu/q-cli/pkg/secretcheck"
+
+// Severity is what happens when a rule finds something.
+type Severity = secretcheck.Severity
+
+const (
+	SeverityWarn    = secretcheck.SeverityWarn
+	SeverityConfirm = secretcheck.SeverityConfirm
+	SeverityBlock   = secretcheck.SeverityBlock
+)
+
+func ParseSeverity(s string) (Severity, error) {
+	return secretcheck.ParseSeverity(s)
+}
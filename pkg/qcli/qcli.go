@@ -0,0 +1,70 @@
+// Package qcli runs the q command, so that tools can embed q-cli with their own extensions.
+package qcli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ikura-hamu/q-cli/internal/client/webhook"
+	"github.com/ikura-hamu/q-cli/internal/cmd"
+	"github.com/ikura-hamu/q-cli/internal/config/file"
+	"github.com/ikura-hamu/q-cli/internal/config/flag"
+	editorImpl "github.com/ikura-hamu/q-cli/internal/editor/impl"
+	"github.com/ikura-hamu/q-cli/internal/message/impl"
+	scanImpl "github.com/ikura-hamu/q-cli/internal/scan/impl"
+	secretImpl "github.com/ikura-hamu/q-cli/internal/secret/impl"
+)
+
+// Main runs the q command with the arguments of the process, and exits with its exit code.
+// Tools embedding q-cli register their secret checkers to secretcheck.DefaultRegistry before calling it.
+func Main() {
+	rootBareCmd := cmd.NewRootBare()
+	confFile := flag.NewFile(rootBareCmd.PersistentFlags())
+	confRoot := flag.NewRoot(rootBareCmd.Flags())
+	v, err := file.NewViper(confFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(cmd.ExitConfig)
+	}
+	confProfile := flag.NewProfile(rootBareCmd.PersistentFlags())
+	confWebhook := file.NewWebhookFactory(v, confProfile)
+	clientFactory := webhook.NewWebhookClientFactory(confWebhook)
+	if err != nil {
+		fmt.Println("create client:", err)
+		os.Exit(1)
+	}
+	mes := impl.NewMessage()
+	confSecret := file.NewSecretFactory(v)
+	confSecretFlag := flag.NewSecret(rootBareCmd.PersistentFlags())
+	secFactory := secretImpl.NewSecretDetectorFactory(confSecret, confSecretFlag)
+	auditFactory := secretImpl.NewAuditFileFactory(confSecret)
+	ed := editorImpl.NewEditor()
+	confTemplate := file.NewTemplateFactory(v)
+
+	rootCmd := cmd.NewRoot(rootBareCmd, confFile, confRoot, clientFactory, mes, secFactory, auditFactory, ed, confTemplate)
+
+	initBareCmd := cmd.NewInitBare(rootCmd)
+	confInit := flag.NewInit(initBareCmd)
+	configFileWriter := file.NewWriter(v)
+	_ = cmd.NewInit(initBareCmd, confInit, configFileWriter)
+
+	confBareCmd := cmd.NewConfigBare()
+	configFileReader := file.NewReader(v, confProfile)
+	_ = cmd.NewConfig(rootCmd, confBareCmd, configFileReader)
+
+	secretCmd := cmd.NewSecret(rootCmd)
+	_ = cmd.NewSecretRules(secretCmd, secFactory)
+	_ = cmd.NewSecretAllow(secretCmd, secretImpl.NewDefaultIgnoreFileFactory())
+	_ = cmd.NewSecretAudit(secretCmd, auditFactory)
+
+	scanBareCmd := cmd.NewScanBare(rootCmd)
+	confScan := flag.NewScan(scanBareCmd)
+	_ = cmd.NewScan(scanBareCmd, confScan, scanImpl.NewSource(), secFactory)
+
+	if err := rootCmd.Execute(); err != nil {
+		if !cmd.Reported(err) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(cmd.ExitCode(err))
+	}
+}
@@ -0,0 +1,39 @@
+// Package secretcheck is how tools embedding q-cli add their own secret checkers.
+// Register a Checker to DefaultRegistry before running the CLI, and every secret detector uses it
+// like a built-in checker, configured by its key:
+//
+//	func main() {
+//		secretcheck.MustRegister(myChecker{})
+//		qcli.Main()
+//	}
+package secretcheck
+
+import "context"
+
+// Key identifies a checker in the config, flags and findings, e.g. "github".
+type Key string
+
+// Finding is a secret found by a Checker at the byte offsets [Start, End) of the text.
+// The detector adds the line, column, severity and fingerprint to it.
+type Finding struct {
+	Start int
+	End   int
+	// Description overrides the description of the checker for this finding. Optional.
+	Description string
+}
+
+// Checker finds one kind of secret in a text.
+type Checker interface {
+	Key() Key
+	Description() string
+	// Check returns the secrets in the text. The detector drops the allowed ones.
+	Check(ctx context.Context, text string) ([]Finding, error)
+}
+
+// KeywordChecker is a Checker whose secrets always contain one of its keywords, or follow one closely.
+// The detector only calls Check with the text around the keywords, which makes checking a large text much faster.
+type KeywordChecker interface {
+	Checker
+	// Keywords are matched case-insensitively.
+	Keywords() []string
+}
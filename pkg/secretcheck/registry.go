@@ -0,0 +1,106 @@
+package secretcheck
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Registration is a checker in a Registry.
+type Registration struct {
+	Checker Checker
+	// OptIn checkers are not used unless they are enabled.
+	OptIn bool
+	// Severity is the severity of the checker unless it is configured.
+	Severity Severity
+}
+
+// RegisterOption changes how a checker is registered.
+type RegisterOption func(r *Registration)
+
+// OptIn makes the checker unused unless it is enabled, e.g. by `--secret-enable`.
+func OptIn() RegisterOption {
+	return func(r *Registration) {
+		r.OptIn = true
+	}
+}
+
+// WithDefaultSeverity sets the severity of the checker unless it is configured. The default is "block".
+func WithDefaultSeverity(sev Severity) RegisterOption {
+	return func(r *Registration) {
+		r.Severity = sev
+	}
+}
+
+// Registry is a set of checkers, which secret detectors are composed of.
+type Registry struct {
+	mu            sync.RWMutex
+	registrations map[Key]Registration
+}
+
+func NewRegistry() *Registry {
+	return &Registry{registrations: make(map[Key]Registration)}
+}
+
+// DefaultRegistry has the built-in checkers, and the checkers registered by tools embedding q-cli.
+var DefaultRegistry = NewRegistry()
+
+// Register adds the checker to DefaultRegistry.
+func Register(c Checker, opts ...RegisterOption) error {
+	return DefaultRegistry.Register(c, opts...)
+}
+
+// MustRegister adds the checker to DefaultRegistry, and panics on failure.
+func MustRegister(c Checker, opts ...RegisterOption) {
+	DefaultRegistry.MustRegister(c, opts...)
+}
+
+// Register adds the checker. It fails when the key is empty, "*" or already registered.
+func (r *Registry) Register(c Checker, opts ...RegisterOption) error {
+	key := c.Key()
+	if key == "" || key == "*" {
+		return fmt.Errorf("invalid checker key '%s'", key)
+	}
+
+	reg := Registration{Checker: c, Severity: SeverityBlock}
+	for _, opt := range opts {
+		opt(&reg)
+	}
+	if _, err := ParseSeverity(string(reg.Severity)); err != nil {
+		return fmt.Errorf("checker '%s': %w", key, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.registrations[key]; ok {
+		return fmt.Errorf("checker '%s' is already registered", key)
+	}
+	r.registrations[key] = reg
+	return nil
+}
+
+// MustRegister is like Register but panics on failure. It is meant for registering checkers in init functions.
+func (r *Registry) MustRegister(c Checker, opts ...RegisterOption) {
+	if err := r.Register(c, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the registration of the checker.
+func (r *Registry) Lookup(key Key) (Registration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reg, ok := r.registrations[key]
+	return reg, ok
+}
+
+// Registrations returns every registered checker, sorted by key.
+func (r *Registry) Registrations() []Registration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.SortedFunc(maps.Values(r.registrations), func(a, b Registration) int {
+		return cmp.Compare(a.Checker.Key(), b.Checker.Key())
+	})
+}
@@ -0,0 +1,23 @@
+package secretcheck
+
+import "fmt"
+
+// Severity is what happens when a checker finds something.
+type Severity string
+
+const (
+	// SeverityWarn prints the finding and sends the message.
+	SeverityWarn Severity = "warn"
+	// SeverityConfirm sends the message only when the user confirms it on the terminal.
+	SeverityConfirm Severity = "confirm"
+	// SeverityBlock refuses to send the message.
+	SeverityBlock Severity = "block"
+)
+
+func ParseSeverity(s string) (Severity, error) {
+	switch sev := Severity(s); sev {
+	case SeverityWarn, SeverityConfirm, SeverityBlock:
+		return sev, nil
+	}
+	return "", fmt.Errorf("invalid severity '%s': must be '%s', '%s' or '%s'", s, SeverityWarn, SeverityConfirm, SeverityBlock)
+}